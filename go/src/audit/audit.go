@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+)
+
+// Record represents a single tool-invocation audit entry
+type Record struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Client     string    `json:"client"`
+	Tool       string    `json:"tool"`
+	ArgsDigest string    `json:"args_digest"`
+	Outcome    string    `json:"outcome"`
+}
+
+// Writer writes append-only audit records as JSON lines
+type Writer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewWriter creates a new audit writer from configuration
+func NewWriter(cfg *config.AuditConfig) (*Writer, error) {
+	var out io.Writer
+	switch cfg.Output {
+	case "", "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	case "file":
+		if cfg.File == "" {
+			return nil, fmt.Errorf("audit output is 'file' but audit.file is not set")
+		}
+		file, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit file: %w", err)
+		}
+		out = file
+	default:
+		return nil, fmt.Errorf("unknown audit output: %s", cfg.Output)
+	}
+
+	return &Writer{out: out}, nil
+}
+
+// Write appends a single audit record
+func (w *Writer) Write(record Record) error {
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err = w.out.Write(append(bytes, '\n'))
+	return err
+}
+
+// Digest returns a hex-encoded SHA-256 digest of the raw tool arguments,
+// so sensitive argument contents never reach the audit log
+func Digest(args []byte) string {
+	sum := sha256.Sum256(args)
+	return hex.EncodeToString(sum[:])
+}
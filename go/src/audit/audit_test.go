@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+)
+
+func TestWriterAppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	writer, err := NewWriter(&config.AuditConfig{Output: "file", File: path})
+	if err != nil {
+		t.Fatalf("NewWriter failed: %v", err)
+	}
+
+	records := []Record{
+		{Timestamp: time.Now().UTC(), Client: "client-1", Tool: "sql_format", ArgsDigest: Digest([]byte(`{"query":"select 1"}`)), Outcome: "success"},
+		{Timestamp: time.Now().UTC(), Client: "client-2", Tool: "query", ArgsDigest: Digest([]byte(`{}`)), Outcome: "error"},
+	}
+	for _, record := range records {
+		if err := writer.Write(record); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open audit file: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for i, want := range records {
+		if !scanner.Scan() {
+			t.Fatalf("audit file has %d lines, want %d", i, len(records))
+		}
+		var got Record
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i+1, err)
+		}
+		if got.Client != want.Client || got.Tool != want.Tool || got.Outcome != want.Outcome || got.ArgsDigest != want.ArgsDigest {
+			t.Errorf("line %d mismatch: got %+v, want %+v", i+1, got, want)
+		}
+	}
+	if scanner.Scan() {
+		t.Errorf("unexpected extra audit line: %q", scanner.Text())
+	}
+}
+
+func TestNewWriterRejectsBadConfig(t *testing.T) {
+	if _, err := NewWriter(&config.AuditConfig{Output: "file"}); err == nil {
+		t.Error("expected an error when audit.file is not set")
+	}
+	if _, err := NewWriter(&config.AuditConfig{Output: "syslog"}); err == nil {
+		t.Error("expected an error for an unknown output")
+	}
+}
+
+func TestDigestIsStableAndContentSensitive(t *testing.T) {
+	first := Digest([]byte(`{"query":"select 1"}`))
+	if len(first) != 64 {
+		t.Fatalf("expected a 64-char hex digest, got %q", first)
+	}
+	if second := Digest([]byte(`{"query":"select 1"}`)); second != first {
+		t.Errorf("digest is not deterministic: %q vs %q", first, second)
+	}
+	if other := Digest([]byte(`{"query":"select 2"}`)); other == first {
+		t.Error("different arguments produced the same digest")
+	}
+}
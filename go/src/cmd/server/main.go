@@ -6,18 +6,38 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"syscall"
 	"time"
 
+	"github.com/cploutarchou/mcp-servers/go/audit"
 	"github.com/cploutarchou/mcp-servers/go/codeassist"
 	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/cploutarchou/mcp-servers/go/database"
+	"github.com/cploutarchou/mcp-servers/go/features"
 	"github.com/cploutarchou/mcp-servers/go/mcp"
 	"github.com/cploutarchou/mcp-servers/go/monitoring"
+	"github.com/cploutarchou/mcp-servers/go/respond"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// app holds every component of the process. buildApp constructs, wires, and
+// warms all of it — handlers, metric collectors, routes — before main starts
+// the listeners, so nothing is ever registered while a server is already
+// accepting requests.
+type app struct {
+	mcpHandler        *mcp.Handler
+	codeAssistHandler *codeassist.Handler
+	healthHandler     *monitoring.HealthHandler
+	metricsHandler    *monitoring.MetricsHandler
+	selfCheckHandler  *monitoring.SelfCheckHandler
+	drainer           *respond.Drainer
+	mainServer        *http.Server
+	metricsServer     *http.Server
+}
+
 func main() {
 	// Create logger
 	logger := logrus.New()
@@ -25,11 +45,13 @@ func main() {
 	logger.SetOutput(os.Stdout)
 	logger.SetLevel(logrus.InfoLevel)
 
-	// Load configuration
-	cfg, err := config.LoadConfig(".")
+	// Load configuration into a store so future hot-reloads can swap it
+	// atomically under concurrent readers
+	configStore, err := config.NewStore(".")
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to load configuration")
 	}
+	cfg := configStore.Current()
 
 	// Set log level
 	level, err := logrus.ParseLevel(cfg.Server.LogLevel)
@@ -37,31 +59,171 @@ func main() {
 		logger.SetLevel(level)
 	}
 
+	// A file key matching no known field is almost always a typo; strict
+	// mode (server.strict_config) turns this into a load failure instead
+	if len(cfg.UnknownKeys) > 0 {
+		logger.WithField("keys", cfg.UnknownKeys).Warn("Config file contains unrecognized keys; check for typos")
+	}
+
+	logStartupSummary(logger, cfg)
+
+	// Build everything up front; a construction failure means the process
+	// exits before binding either port
+	application, err := buildApp(cfg, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to build server")
+	}
+
+	// Start servers in goroutines
+	go func() {
+		logger.WithFields(logrus.Fields{
+			"host": cfg.Server.Host,
+			"port": cfg.Server.Port,
+		}).Info("Starting MCP server")
+
+		if err := application.mainServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Fatal("Failed to start MCP server")
+		}
+	}()
+
+	go func() {
+		logger.WithFields(logrus.Fields{
+			"host": cfg.Server.Host,
+			"port": cfg.Server.MetricsPort,
+		}).Info("Starting metrics server")
+
+		if err := application.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.WithError(err).Fatal("Failed to start metrics server")
+		}
+	}()
+
+	// Reload configuration on SIGHUP; clients are notified when the
+	// advertised capabilities change
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			newCfg, err := configStore.Reload()
+			if err != nil {
+				logger.WithError(err).Error("Config reload failed; keeping previous configuration")
+				continue
+			}
+			application.mcpHandler.ApplyConfig(&newCfg.MCP, newCfg.Server.SafeMode)
+			if len(newCfg.UnknownKeys) > 0 {
+				logger.WithField("keys", newCfg.UnknownKeys).Warn("Config file contains unrecognized keys; check for typos")
+			}
+			logger.Info("Configuration reloaded")
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown the server
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	logger.Info("Shutting down servers...")
+
+	// New requests now get a clean 503 with Retry-After instead of a
+	// connection error while in-flight ones drain
+	application.drainer.Drain()
+
+	// Create context with timeout for shutdown
+	shutdownTimeout, err := time.ParseDuration(cfg.Server.ShutdownTimeout)
+	if err != nil {
+		shutdownTimeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Shutdown servers
+	if err := application.mainServer.Shutdown(ctx); err != nil {
+		logger.WithError(err).Fatal("MCP server forced to shutdown")
+	}
+
+	if err := application.metricsServer.Shutdown(ctx); err != nil {
+		logger.WithError(err).Fatal("Metrics server forced to shutdown")
+	}
+
+	logger.Info("Servers exited properly")
+}
+
+// buildApp constructs and wires every handler, route, and server from the
+// configuration. It returns an error rather than exiting, so main can fail
+// cleanly before any listener binds.
+func buildApp(cfg *config.Config, logger *logrus.Logger) (*app, error) {
 	// Set Gin mode
 	if !cfg.Server.Debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// Reject new requests with a clean 503 once graceful shutdown begins;
+	// Retry-After advertises the drain window
+	shutdownTimeout, err := time.ParseDuration(cfg.Server.ShutdownTimeout)
+	if err != nil {
+		shutdownTimeout = 10 * time.Second
+	}
+	drainer := respond.NewDrainer(shutdownTimeout)
+
 	// Create router
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(drainer.Middleware())
+
+	// Only honor X-Forwarded-For from the configured proxies, so logging and
+	// auditing record accurate client IPs
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("failed to set trusted proxies: %w", err)
+	}
 
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     cfg.Server.CORS.AllowedOrigins,
 		AllowMethods:     cfg.Server.CORS.AllowedMethods,
-		AllowHeaders:     cfg.Server.CORS.AllowedHeaders,
+		AllowHeaders:     cfg.Server.CORS.EffectiveAllowedHeaders(),
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	// Merge configured language aliases and extension mappings over the
+	// built-in defaults
+	codeassist.SetLanguageAliases(cfg.MCP.LanguageAliases)
+	codeassist.SetExtensionLanguages(cfg.MCP.LanguageExtensions)
+
 	// Create handlers
 	mcpHandler := mcp.NewHandler(&cfg.MCP, logger)
+	mcpHandler.SetSafeMode(cfg.Server.SafeMode)
+	// Safe mode never touches the database, so don't even configure it
+	if cfg.Database.ConnectionString != "" && !cfg.Server.SafeMode {
+		dbClient, err := database.NewClient(&cfg.Database, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure database: %w", err)
+		}
+		mcpHandler.SetDatabase(dbClient)
+	}
+	if cfg.Audit.Enabled {
+		auditWriter, err := audit.NewWriter(&cfg.Audit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create audit writer: %w", err)
+		}
+		mcpHandler.SetAuditWriter(auditWriter)
+	}
 	healthHandler := monitoring.NewHealthHandler(&cfg.MCP, logger)
-	metricsHandler := monitoring.NewMetricsHandler(logger)
+	if timeout, err := time.ParseDuration(cfg.Monitoring.HealthCheckTimeout); err == nil {
+		healthHandler.SetCheckTimeout(timeout)
+	}
+	if cacheTTL, err := time.ParseDuration(cfg.Monitoring.HealthCheckCache); err == nil {
+		healthHandler.SetCacheTTL(cacheTTL)
+	}
+	metricsHandler := monitoring.NewMetricsHandler(&cfg.Monitoring.Metrics, logger)
+	mcpHandler.SetSSEDurationObserver(metricsHandler.ObserveSSESessionDuration)
+	mcpHandler.SetToolMetricsObserver(metricsHandler)
+	mcpHandler.SetSSEDropObserver(metricsHandler.IncrementSSESlowConsumerDrops)
+	mcpHandler.SetCacheMetricsObserver(metricsHandler)
+	mcpHandler.SetAnalysisMetricsObserver(metricsHandler)
 
 	// Add middleware
 	router.Use(metricsHandler.MetricsMiddleware())
+	router.Use(features.Middleware(cfg.Server.Features))
 
 	// Add logging middleware
 	router.Use(func(c *gin.Context) {
@@ -80,74 +242,193 @@ func main() {
 		}).Info("Request processed")
 	})
 
-	// Register routes
-	router.POST("/", mcpHandler.HandleMCPRequest)
-	router.GET("/sse", mcpHandler.HandleSSE)
-	router.GET("/health", healthHandler.HandleHealthCheck)
-	
+	// Register routes under the configured base path (empty serves from the
+	// root), so the server can sit behind a path-based ingress. The metrics
+	// server below intentionally stays at the root.
+	base := router.Group(cfg.Server.BasePath)
+	base.POST("/", respond.RequireJSON(), mcpHandler.HandleMCPRequest)
+	if cfg.MCP.SSEEnabled {
+		base.GET("/sse", mcpHandler.HandleSSE)
+	}
+	base.GET("/query/export", mcpHandler.HandleQueryExport)
+	base.GET("/schema", mcpHandler.HandleSchema)
+	base.GET("/health", healthHandler.HandleHealthCheck)
+
 	// Register code assistance routes
 	codeAssistHandler := codeassist.NewHandler(logger)
-	codeAssistHandler.RegisterRoutes(router)
+	codeAssistHandler.RegisterRoutes(base)
 
-	// Create metrics server
-	metricsRouter := gin.New()
-	metricsRouter.Use(gin.Recovery())
-	metricsRouter.GET("/metrics", metricsHandler.HandleMetrics)
-	metricsRouter.GET("/health", healthHandler.HandleHealthCheck)
+	// Self-check report for ops: what the process is actually wired with,
+	// assembled from the live components rather than the config
+	selfCheckHandler := monitoring.NewSelfCheckHandler(logger)
+	selfCheckHandler.AddSection("config", func() interface{} {
+		return gin.H{"validated": true, "debug": cfg.Server.Debug, "safeMode": cfg.Server.SafeMode}
+	})
+	selfCheckHandler.AddSection("methods", func() interface{} {
+		return mcpHandler.RegisteredMethodNames()
+	})
+	selfCheckHandler.AddSection("tools", func() interface{} {
+		return mcpHandler.AdvertisedToolNames()
+	})
+	selfCheckHandler.AddSection("caches", func() interface{} {
+		return gin.H{"mcp": mcpHandler.CacheSizes(), "codeassist": codeAssistHandler.CacheSizes()}
+	})
+	selfCheckHandler.AddSection("database", func() interface{} {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		return mcpHandler.DatabaseStatus(ctx)
+	})
+	selfCheckHandler.AddSection("metrics", func() interface{} {
+		return gin.H{"collectors": metricsHandler.CollectorCount()}
+	})
 
-	// Start servers
-	mainServer := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
-		Handler: router,
+	// Debug mode exposes the report on the main port; it is always served
+	// from the internal metrics port behind that port's auth
+	if cfg.Server.Debug {
+		base.GET("/debug/selfcheck", selfCheckHandler.HandleSelfCheck)
 	}
 
-	metricsServer := &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.MetricsPort),
-		Handler: metricsRouter,
+	// Unknown paths and methods answer in the API's JSON shape instead of
+	// Gin's plain-text defaults, with a hint listing the registered routes.
+	// The metrics server keeps the defaults since it's internal.
+	routesHint := func() []string {
+		routes := make([]string, 0, len(router.Routes()))
+		for _, route := range router.Routes() {
+			routes = append(routes, route.Method+" "+route.Path)
+		}
+		sort.Strings(routes)
+		return routes
 	}
+	router.NoRoute(func(c *gin.Context) {
+		respond.JSON(c, http.StatusNotFound, gin.H{
+			"error":  fmt.Sprintf("no route for %s %s", c.Request.Method, c.Request.URL.Path),
+			"routes": routesHint(),
+		})
+	})
+	router.HandleMethodNotAllowed = true
+	router.NoMethod(func(c *gin.Context) {
+		respond.JSON(c, http.StatusMethodNotAllowed, gin.H{
+			"error":  fmt.Sprintf("method %s not allowed for %s", c.Request.Method, c.Request.URL.Path),
+			"routes": routesHint(),
+		})
+	})
 
-	// Start servers in goroutines
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"host": cfg.Server.Host,
-			"port": cfg.Server.Port,
-		}).Info("Starting MCP server")
+	// Apply analysis configuration to both dispatch paths
+	mcpHandler.SetSeverityOverrides(cfg.Analysis.SeverityOverrides)
+	codeAssistHandler.SetSeverityOverrides(cfg.Analysis.SeverityOverrides)
+	mcpHandler.SetDisabledRules(cfg.Analysis.DisabledRules)
+	codeAssistHandler.SetDisabledRules(cfg.Analysis.DisabledRules)
+	mcpHandler.SetMaxLineLength(cfg.Analysis.MaxLineLength)
+	codeAssistHandler.SetMaxLineLength(cfg.Analysis.MaxLineLength)
+	codeAssistHandler.SetMaxCompletionBytes(cfg.MCP.MaxCompletionBytes)
+	mcpHandler.SetDocCacheMaxEntries(cfg.Documentation.CacheMaxEntries)
+	codeAssistHandler.SetDocCacheMaxEntries(cfg.Documentation.CacheMaxEntries)
+	if completionTimeout, err := time.ParseDuration(cfg.Completion.Timeout); err == nil {
+		mcpHandler.SetCompletionTimeout(completionTimeout)
+		codeAssistHandler.SetCompletionTimeout(completionTimeout)
+	}
+	if len(cfg.Completion.Popularity) > 0 {
+		mcpHandler.SetCompletionPopularity(cfg.Completion.Popularity)
+		codeAssistHandler.SetCompletionPopularity(cfg.Completion.Popularity)
+	}
+	codeAssistHandler.SetCacheMetricsObserver(metricsHandler)
+	codeAssistHandler.SetAnalysisMetricsObserver(metricsHandler)
+	codeAssistHandler.SetStrictParams(cfg.MCP.StrictParams)
 
-		if err := mainServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start MCP server")
+	// Load additional documentation sources over the built-ins
+	if len(cfg.Documentation.Sources) > 0 {
+		if err := mcpHandler.LoadDocumentationSources(cfg.Documentation.Sources); err != nil {
+			return nil, fmt.Errorf("failed to load documentation sources: %w", err)
 		}
-	}()
-
-	go func() {
-		logger.WithFields(logrus.Fields{
-			"host": cfg.Server.Host,
-			"port": cfg.Server.MetricsPort,
-		}).Info("Starting metrics server")
+		if err := codeAssistHandler.LoadDocumentationSources(cfg.Documentation.Sources); err != nil {
+			return nil, fmt.Errorf("failed to load documentation sources: %w", err)
+		}
+	}
 
-		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.WithError(err).Fatal("Failed to start metrics server")
+	// Load extra per-language completion keywords over the built-ins
+	if cfg.Completion.Keywords != "" {
+		if err := mcpHandler.LoadCompletionKeywords(cfg.Completion.Keywords); err != nil {
+			return nil, fmt.Errorf("failed to load completion keywords: %w", err)
 		}
-	}()
+		if err := codeAssistHandler.LoadCompletionKeywords(cfg.Completion.Keywords); err != nil {
+			return nil, fmt.Errorf("failed to load completion keywords: %w", err)
+		}
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Prime analyzers and caches so the first request is not cold
+	if err := mcpHandler.Warmup(); err != nil {
+		logger.WithError(err).Warn("Code assistance warmup failed")
+	}
+	if err := codeAssistHandler.Warmup(); err != nil {
+		logger.WithError(err).Warn("Code assistance warmup failed")
+	}
 
-	logger.Info("Shutting down servers...")
+	// Create metrics server
+	metricsRouter := gin.New()
+	metricsRouter.Use(gin.Recovery())
+	metricsRouter.Use(drainer.Middleware())
+	metricsRouter.GET("/metrics", metricsHandler.AuthMiddleware(), metricsHandler.HandleMetrics)
+	metricsRouter.GET("/health", healthHandler.HandleHealthCheck)
+	metricsRouter.GET("/debug/selfcheck", metricsHandler.AuthMiddleware(), selfCheckHandler.HandleSelfCheck)
 
-	// Create context with timeout for shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Parse connection timeouts (validated at config load)
+	readTimeout, _ := time.ParseDuration(cfg.Server.Timeouts.Read)
+	readHeaderTimeout, _ := time.ParseDuration(cfg.Server.Timeouts.ReadHeader)
+	writeTimeout, _ := time.ParseDuration(cfg.Server.Timeouts.Write)
+	idleTimeout, _ := time.ParseDuration(cfg.Server.Timeouts.Idle)
 
-	// Shutdown servers
-	if err := mainServer.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("MCP server forced to shutdown")
+	// The main server carries the long-lived SSE stream, so it deliberately
+	// has no WriteTimeout; a write deadline would kill the stream.
+	mainServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port),
+		Handler:           router,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
 	}
 
-	if err := metricsServer.Shutdown(ctx); err != nil {
-		logger.WithError(err).Fatal("Metrics server forced to shutdown")
+	metricsServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.MetricsPort),
+		Handler:           metricsRouter,
+		ReadTimeout:       readTimeout,
+		ReadHeaderTimeout: readHeaderTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
 	}
 
-	logger.Info("Servers exited properly")
+	return &app{
+		mcpHandler:        mcpHandler,
+		codeAssistHandler: codeAssistHandler,
+		healthHandler:     healthHandler,
+		metricsHandler:    metricsHandler,
+		selfCheckHandler:  selfCheckHandler,
+		drainer:           drainer,
+		mainServer:        mainServer,
+		metricsServer:     metricsServer,
+	}, nil
+}
+
+// logStartupSummary emits a single structured log describing the effective
+// configuration, so the process setup is obvious from the logs. Secrets such
+// as the DSN and metrics bearer token are reduced to yes/no indicators.
+func logStartupSummary(logger *logrus.Logger, cfg *config.Config) {
+	metricsAuth := "open"
+	if cfg.Monitoring.Metrics.Auth.BearerToken != "" {
+		metricsAuth = "bearer"
+	} else if len(cfg.Monitoring.Metrics.Auth.AllowedIPs) > 0 {
+		metricsAuth = "ip_allowlist"
+	}
+
+	logger.WithFields(logrus.Fields{
+		"port":               cfg.Server.Port,
+		"metricsPort":        cfg.Server.MetricsPort,
+		"safeMode":           cfg.Server.SafeMode,
+		"supportedLanguages": cfg.MCP.Capabilities.SupportedLanguages,
+		"supportsStreaming":  cfg.MCP.Capabilities.SupportsStreaming,
+		"databaseConfigured": cfg.Database.ConnectionString != "",
+		"upstreamConfigured": cfg.MCP.UpstreamURL != "",
+		"auditEnabled":       cfg.Audit.Enabled,
+		"metricsNamespace":   cfg.Monitoring.Metrics.Namespace,
+		"metricsAuth":        metricsAuth,
+	}).Info("Effective server configuration")
 }
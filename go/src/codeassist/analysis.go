@@ -5,38 +5,154 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// maxLineScanLength caps how many bytes of a line the per-line regexes scan,
+// so a single pathological line (e.g. minified output) stays cheap
+const maxLineScanLength = 1024
+
+// Rule regexes are compiled once at package load rather than per line
+var (
+	updateOrDeleteRegex = regexp.MustCompile(`(?i)(UPDATE|DELETE FROM)\s+\w+\s+(SET\s+.*\s+)?(ORDER BY|LIMIT|$)`)
+	credentialRegex     = regexp.MustCompile(`(?i)(password|passwd|pwd|secret|key|token|api_key|apikey)\s*[=:]\s*['"][^'"]*['"]`)
+	aggregateRegex      = regexp.MustCompile(`(?i)\b(COUNT|SUM|AVG|MIN|MAX)\s*\(|GROUP\s+BY`)
+	funcNameRegex       = regexp.MustCompile(`func\s+(?:\([^)]*\)\s+)?([A-Za-z0-9_]+)\s*\(`)
+	pythonStringRegex   = regexp.MustCompile(`(?i:[frb]{0,2})("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`)
+	pythonFStringRegex  = regexp.MustCompile(`\b(?i:f[rb]?|[rb]f)("(?:[^"\\]|\\.)*"|'(?:[^'\\]|\\.)*')`)
+)
+
+// capLineForScan truncates a line to the maximum length scanned by regex rules
+func capLineForScan(line string) string {
+	if len(line) > maxLineScanLength {
+		return line[:maxLineScanLength]
+	}
+	return line
+}
+
+// defaultMaxLineLength is the line length limit applied when none is configured
+const defaultMaxLineLength = 100
+
 // AnalysisHandler handles code analysis requests
 type AnalysisHandler struct {
-	logger *logrus.Logger
+	logger            *logrus.Logger
+	severityOverrides map[string]string
+	disabledRules     map[string]bool
+	maxLineLength     int
+	metricsObserver   AnalysisMetricsObserver
 }
 
 // NewAnalysisHandler creates a new analysis handler
 func NewAnalysisHandler(logger *logrus.Logger) *AnalysisHandler {
 	return &AnalysisHandler{
-		logger: logger,
+		logger:        logger,
+		maxLineLength: defaultMaxLineLength,
+	}
+}
+
+// SetMaxLineLength sets the limit used by the long-line rule. Non-positive
+// values keep the default.
+func (h *AnalysisHandler) SetMaxLineLength(limit int) {
+	if limit > 0 {
+		h.maxLineLength = limit
+	}
+}
+
+// SetSeverityOverrides sets per-rule severity overrides applied after analysis rules run
+func (h *AnalysisHandler) SetSeverityOverrides(overrides map[string]string) {
+	h.severityOverrides = overrides
+}
+
+// AnalysisMetricsObserver receives per-severity diagnostic counts from
+// completed analysis runs (e.g. into Prometheus counters)
+type AnalysisMetricsObserver interface {
+	AnalysisDiagnostics(language, severity string, count int)
+}
+
+// SetMetricsObserver registers a receiver for diagnostic counts
+func (h *AnalysisHandler) SetMetricsObserver(observer AnalysisMetricsObserver) {
+	h.metricsObserver = observer
+}
+
+// SetDisabledRules disables the given rule ids; their diagnostics are dropped
+func (h *AnalysisHandler) SetDisabledRules(ruleIDs []string) {
+	h.disabledRules = make(map[string]bool, len(ruleIDs))
+	for _, ruleID := range ruleIDs {
+		h.disabledRules[ruleID] = true
+	}
+}
+
+// filterDisabledRules drops diagnostics produced by disabled rules
+func (h *AnalysisHandler) filterDisabledRules(diagnostics []AnalysisDiagnostic) []AnalysisDiagnostic {
+	if len(h.disabledRules) == 0 {
+		return diagnostics
 	}
+
+	filtered := diagnostics[:0]
+	for _, diag := range diagnostics {
+		if diag.Code != "" && h.disabledRules[diag.Code] {
+			continue
+		}
+		filtered = append(filtered, diag)
+	}
+
+	return filtered
 }
 
 // AnalysisRequest represents a code analysis request
 type AnalysisRequest struct {
 	Code     string `json:"code"`
 	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
 	Context  string `json:"context,omitempty"`
+	Profile  bool   `json:"profile,omitempty"`
+	Compact  bool   `json:"compact,omitempty"`
+}
+
+// RuleAnalysisRequest represents a request to run a single analysis rule
+type RuleAnalysisRequest struct {
+	RuleID   string `json:"ruleId"`
+	Code     string `json:"code"`
+	Language string `json:"language"`
+}
+
+// AnalysisTimings breaks down where analysis time went, in milliseconds. It is
+// only populated when the request sets profile.
+type AnalysisTimings struct {
+	AnalyzeMs     float64 `json:"analyzeMs"`
+	PostProcessMs float64 `json:"postProcessMs"`
+	SummaryMs     float64 `json:"summaryMs"`
+	TotalMs       float64 `json:"totalMs"`
 }
 
 // AnalysisDiagnostic represents a diagnostic issue found in code
 type AnalysisDiagnostic struct {
-	Message  string `json:"message"`
-	Severity string `json:"severity"` // "error", "warning", "info", "hint"
-	Line     int    `json:"line"`
-	Column   int    `json:"column"`
-	EndLine  int    `json:"endLine,omitempty"`
-	EndCol   int    `json:"endColumn,omitempty"`
-	Code     string `json:"code,omitempty"`
+	Message     string       `json:"message"`
+	Severity    string       `json:"severity"` // "error", "warning", "info", "hint"
+	Line        int          `json:"line"`
+	Column      int          `json:"column"`
+	EndLine     int          `json:"endLine,omitempty"`
+	EndCol      int          `json:"endColumn,omitempty"`
+	Code        string       `json:"code,omitempty"`
+	CodeActions []CodeAction `json:"codeActions,omitempty"`
+}
+
+// CodeAction is a quick fix attached to a diagnostic, built from the
+// suggestion at the same position so a diagnostic and its fix travel together
+type CodeAction struct {
+	Title string           `json:"title"`
+	Edits []CodeActionEdit `json:"edits"`
+}
+
+// CodeActionEdit is a single text replacement applied by a code action
+type CodeActionEdit struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndLine   int    `json:"endLine,omitempty"`
+	EndColumn int    `json:"endColumn,omitempty"`
+	NewText   string `json:"newText"`
 }
 
 // AnalysisSuggestion represents a suggestion for improving code
@@ -54,6 +170,26 @@ type AnalysisResponse struct {
 	Diagnostics []AnalysisDiagnostic `json:"diagnostics"`
 	Suggestions []AnalysisSuggestion `json:"suggestions"`
 	Summary     string               `json:"summary"`
+	Timings     *AnalysisTimings     `json:"timings,omitempty"`
+}
+
+// CompactAnalysisResponse mirrors AnalysisResponse but omits empty fields,
+// for clients on slow links that request compact output
+type CompactAnalysisResponse struct {
+	Diagnostics []AnalysisDiagnostic `json:"diagnostics,omitempty"`
+	Suggestions []AnalysisSuggestion `json:"suggestions,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Timings     *AnalysisTimings     `json:"timings,omitempty"`
+}
+
+// Compact returns a view of the response with empty fields omitted
+func (r *AnalysisResponse) Compact() *CompactAnalysisResponse {
+	return &CompactAnalysisResponse{
+		Diagnostics: r.Diagnostics,
+		Suggestions: r.Suggestions,
+		Summary:     r.Summary,
+		Timings:     r.Timings,
+	}
 }
 
 // AnalyzeCode analyzes code and returns diagnostics and suggestions
@@ -63,6 +199,16 @@ func (h *AnalysisHandler) AnalyzeCode(request *AnalysisRequest) (*AnalysisRespon
 		"codeSize": len(request.Code),
 	}).Info("Processing analysis request")
 
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	start := time.Now()
+
 	// Process based on language
 	var diagnostics []AnalysisDiagnostic
 	var suggestions []AnalysisSuggestion
@@ -83,13 +229,115 @@ func (h *AnalysisHandler) AnalyzeCode(request *AnalysisRequest) (*AnalysisRespon
 		return nil, err
 	}
 
+	analyzed := time.Now()
+
+	// Drop diagnostics from disabled rules and apply configured severity
+	// overrides before computing the summary
+	diagnostics = h.filterDisabledRules(diagnostics)
+	diagnostics = h.applySeverityOverrides(diagnostics)
+	diagnostics = attachCodeActions(diagnostics, suggestions)
+
+	postProcessed := time.Now()
+
 	// Generate a summary
 	summary := h.generateSummary(diagnostics, suggestions)
 
-	return &AnalysisResponse{
+	// Report the per-severity breakdown after overrides, so the metrics
+	// match what the caller actually sees
+	if h.metricsObserver != nil {
+		counts := make(map[string]int)
+		for _, diagnostic := range diagnostics {
+			counts[diagnostic.Severity]++
+		}
+		for severity, count := range counts {
+			h.metricsObserver.AnalysisDiagnostics(request.Language, severity, count)
+		}
+	}
+
+	response := &AnalysisResponse{
 		Diagnostics: diagnostics,
 		Suggestions: suggestions,
 		Summary:     summary,
+	}
+
+	// Only break down timings when profiling was requested, so the common
+	// path stays free of the extra response weight
+	if request.Profile {
+		done := time.Now()
+		response.Timings = &AnalysisTimings{
+			AnalyzeMs:     float64(analyzed.Sub(start).Microseconds()) / 1000,
+			PostProcessMs: float64(postProcessed.Sub(analyzed).Microseconds()) / 1000,
+			SummaryMs:     float64(done.Sub(postProcessed).Microseconds()) / 1000,
+			TotalMs:       float64(done.Sub(start).Microseconds()) / 1000,
+		}
+	}
+
+	return response, nil
+}
+
+// AnalyzeRule runs a single registered rule against code and returns only its
+// diagnostics, so rule authors can iterate without noise from other rules.
+// Disabled-rule filtering is deliberately skipped: a disabled rule can still
+// be exercised here.
+func (h *AnalysisHandler) AnalyzeRule(rule AnalysisRule, request *RuleAnalysisRequest) (*AnalysisResponse, error) {
+	h.logger.WithFields(logrus.Fields{
+		"ruleId":   rule.ID,
+		"language": request.Language,
+		"codeSize": len(request.Code),
+	}).Info("Processing single-rule analysis request")
+
+	// Route to the analyzer that owns the rule; the request language only
+	// matters for rules that apply to any language
+	language := rule.Language
+	if language == "any" {
+		language = NormalizeLanguage(request.Language)
+	}
+
+	var diagnostics []AnalysisDiagnostic
+	var suggestions []AnalysisSuggestion
+	var err error
+
+	switch strings.ToLower(language) {
+	case "go":
+		diagnostics, suggestions, err = h.analyzeGoCode(request.Code)
+	case "python":
+		diagnostics, suggestions, err = h.analyzePythonCode(request.Code)
+	case "sql":
+		diagnostics, suggestions, err = h.analyzeSQLCode(request.Code)
+	default:
+		diagnostics, suggestions, err = h.analyzeGenericCode(request.Code)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep only the requested rule's diagnostics and the suggestions
+	// positioned alongside them
+	kept := []AnalysisDiagnostic{}
+	for _, diag := range diagnostics {
+		if diag.Code == rule.ID {
+			kept = append(kept, diag)
+		}
+	}
+
+	keptSuggestions := []AnalysisSuggestion{}
+	for _, suggestion := range suggestions {
+		for _, diag := range kept {
+			if suggestion.Line == diag.Line {
+				keptSuggestions = append(keptSuggestions, suggestion)
+				break
+			}
+		}
+	}
+
+	kept = h.applySeverityOverrides(kept)
+	kept = attachCodeActions(kept, keptSuggestions)
+
+	return &AnalysisResponse{
+		Diagnostics: kept,
+		Suggestions: keptSuggestions,
+		Summary:     h.generateSummary(kept, keptSuggestions),
 	}, nil
 }
 
@@ -100,10 +348,39 @@ func (h *AnalysisHandler) analyzeGoCode(code string) ([]AnalysisDiagnostic, []An
 
 	lines := strings.Split(code, "\n")
 
+	// Whether fmt is available for error wrapping suggestions
+	importsFmt := strings.Contains(code, "\"fmt\"")
+
+	// The enclosing function name, tracked for the error-wrapping rule
+	currentFunc := ""
+
 	// Check for common Go issues
 	for i, line := range lines {
 		lineNum := i + 1
 
+		if match := funcNameRegex.FindStringSubmatch(line); match != nil {
+			currentFunc = match[1]
+		}
+
+		// Check for bare error returns in operation-like functions, where a
+		// %w wrap adds the context callers need. Only suggested when fmt is
+		// already imported, to keep the fix a one-liner.
+		if importsFmt && strings.TrimSpace(line) == "return err" && looksLikeOperation(currentFunc) {
+			diagnostics = append(diagnostics, AnalysisDiagnostic{
+				Message:  fmt.Sprintf("Bare error return from %s loses context; consider wrapping with %%w", currentFunc),
+				Severity: "info",
+				Line:     lineNum,
+				Column:   strings.Index(line, "return") + 1,
+				Code:     "go/unwrapped-error",
+			})
+			suggestions = append(suggestions, AnalysisSuggestion{
+				Message:     "Wrap the error with context",
+				Replacement: fmt.Sprintf("return fmt.Errorf(\"%s: %%w\", err)", strings.ToLower(currentFunc)),
+				Line:        lineNum,
+				Column:      strings.Index(line, "return") + 1,
+			})
+		}
+
 		// Check for unused imports
 		if strings.Contains(line, "import") && strings.Contains(line, "\"") {
 			importName := strings.TrimSpace(strings.Split(strings.Split(line, "\"")[1], "\"")[0])
@@ -113,6 +390,7 @@ func (h *AnalysisHandler) analyzeGoCode(code string) ([]AnalysisDiagnostic, []An
 					Severity: "warning",
 					Line:     lineNum,
 					Column:   strings.Index(line, "\"") + 1,
+					Code:     "go/unused-import",
 				})
 			}
 		}
@@ -140,6 +418,7 @@ func (h *AnalysisHandler) analyzeGoCode(code string) ([]AnalysisDiagnostic, []An
 				Severity: "info",
 				Line:     lineNum,
 				Column:   0,
+				Code:     "go/naked-return",
 			})
 		}
 
@@ -155,9 +434,34 @@ func (h *AnalysisHandler) analyzeGoCode(code string) ([]AnalysisDiagnostic, []An
 		}
 	}
 
+	// Flag goroutines that block without any cancellation mechanism
+	diagnostics = append(diagnostics, goroutineLeakDiagnostics(code)...)
+
+	// Flag opened resources that are never closed
+	diagnostics = append(diagnostics, missingCloseDiagnostics(code)...)
+
 	return diagnostics, suggestions, nil
 }
 
+// operationVerbs are function-name prefixes that suggest an operation whose
+// errors benefit from wrapped context
+var operationVerbs = []string{
+	"load", "save", "read", "write", "open", "close", "create", "delete",
+	"update", "fetch", "parse", "connect", "send", "exec", "run", "get", "put",
+}
+
+// looksLikeOperation reports whether a function name starts with an operation
+// verb (e.g. LoadConfig, parseHeader)
+func looksLikeOperation(name string) bool {
+	lower := strings.ToLower(name)
+	for _, verb := range operationVerbs {
+		if strings.HasPrefix(lower, verb) {
+			return true
+		}
+	}
+	return false
+}
+
 // analyzePythonCode analyzes Python code
 func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic, []AnalysisSuggestion, error) {
 	diagnostics := []AnalysisDiagnostic{}
@@ -177,6 +481,7 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 				Severity: "error",
 				Line:     lineNum,
 				Column:   strings.Index(line, "print") + 1,
+				Code:     "python/print-statement",
 			})
 			suggestions = append(suggestions, AnalysisSuggestion{
 				Message:     "Use print() function syntax",
@@ -193,6 +498,7 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 				Severity: "warning",
 				Line:     lineNum,
 				Column:   0,
+				Code:     "python/bare-except",
 			})
 			suggestions = append(suggestions, AnalysisSuggestion{
 				Message:     "Specify exception type",
@@ -202,13 +508,16 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 			})
 		}
 
-		// Check for mutable default arguments
-		if strings.Contains(line, "def ") && (strings.Contains(line, "=[]") || strings.Contains(line, "= []") || strings.Contains(line, "={}") || strings.Contains(line, "= {}")) {
+		// Check for mutable default arguments. String literal content is
+		// stripped first so braces inside f-strings cannot trip the check,
+		// and walrus assignments (:=) are not defaults.
+		if strings.Contains(line, "def ") && hasMutableDefault(stripPythonStrings(capLineForScan(line))) {
 			diagnostics = append(diagnostics, AnalysisDiagnostic{
 				Message:  "Mutable default argument can cause unexpected behavior",
 				Severity: "warning",
 				Line:     lineNum,
 				Column:   0,
+				Code:     "python/mutable-default-arg",
 			})
 			suggestions = append(suggestions, AnalysisSuggestion{
 				Message: "Use None as default and initialize in function body",
@@ -217,6 +526,28 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 			})
 		}
 
+		// Check for f-strings with no placeholders, where the f prefix does
+		// nothing and usually signals a forgotten interpolation
+		for _, loc := range pythonFStringRegex.FindAllStringSubmatchIndex(capLineForScan(line), -1) {
+			literal := line[loc[2]:loc[3]]
+			if strings.Contains(literal, "{") {
+				continue
+			}
+			diagnostics = append(diagnostics, AnalysisDiagnostic{
+				Message:  "f-string has no placeholders",
+				Severity: "info",
+				Line:     lineNum,
+				Column:   loc[0] + 1,
+				Code:     "python/fstring-no-placeholder",
+			})
+			suggestions = append(suggestions, AnalysisSuggestion{
+				Message:     "Remove the unnecessary f prefix",
+				Replacement: literal,
+				Line:        lineNum,
+				Column:      loc[0] + 1,
+			})
+		}
+
 		// Check for unused imports
 		if strings.HasPrefix(trimmedLine, "import ") || strings.HasPrefix(trimmedLine, "from ") {
 			importName := ""
@@ -231,18 +562,19 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 					if len(importParts) > 1 {
 						importName = strings.TrimSpace(importParts[1])
 						if importName == "*" {
-							importName = ""  // Skip wildcard imports
+							importName = "" // Skip wildcard imports
 						}
 					}
 				}
 			}
-			
+
 			if importName != "" && !strings.Contains(code, importName+".") && !strings.Contains(code, " "+importName+" ") && !strings.Contains(code, "("+importName+")") {
 				diagnostics = append(diagnostics, AnalysisDiagnostic{
 					Message:  fmt.Sprintf("Unused import: %s", importName),
 					Severity: "warning",
 					Line:     lineNum,
 					Column:   0,
+					Code:     "python/unused-import",
 				})
 			}
 		}
@@ -251,13 +583,134 @@ func (h *AnalysisHandler) analyzePythonCode(code string) ([]AnalysisDiagnostic,
 	return diagnostics, suggestions, nil
 }
 
-// analyzeSQLCode analyzes SQL code
+// stripPythonStrings blanks out the contents of string literals, including
+// f-, r-, and b-prefixed ones, so line checks are not confused by characters
+// inside them
+func stripPythonStrings(line string) string {
+	return pythonStringRegex.ReplaceAllStringFunc(line, func(match string) string {
+		quote := match[len(match)-1:]
+		return quote + quote
+	})
+}
+
+// hasMutableDefault reports whether a line assigns a mutable literal as a
+// default value. Walrus assignments (:=) are new bindings, not defaults.
+func hasMutableDefault(line string) bool {
+	for _, pattern := range []string{"=[]", "= []", "={}", "= {}"} {
+		offset := 0
+		for {
+			found := strings.Index(line[offset:], pattern)
+			if found == -1 {
+				break
+			}
+			pos := offset + found
+			if pos == 0 || line[pos-1] != ':' {
+				return true
+			}
+			offset = pos + len(pattern)
+		}
+	}
+	return false
+}
+
+// sqlStatement is one statement of a script with the absolute line it starts on
+type sqlStatement struct {
+	text      string
+	startLine int
+}
+
+// splitSQLStatements splits a script on semicolons, respecting semicolons
+// inside single- or double-quoted literals, and records the absolute start
+// line of each statement so diagnostics can be mapped back
+func splitSQLStatements(code string) []sqlStatement {
+	statements := []sqlStatement{}
+	var quote byte
+	line := 1
+	startLine := 1
+	start := 0
+
+	flush := func(end int) {
+		text := code[start:end]
+		textStart := startLine
+		// Trim leading whitespace, keeping the start line accurate
+		for len(text) > 0 && (text[0] == ' ' || text[0] == '\t' || text[0] == '\r' || text[0] == '\n') {
+			if text[0] == '\n' {
+				textStart++
+			}
+			text = text[1:]
+		}
+		if strings.TrimSpace(text) != "" {
+			statements = append(statements, sqlStatement{text: text, startLine: textStart})
+		}
+	}
+
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '\n':
+			line++
+		case quote != 0:
+			if c == quote {
+				// A doubled quote is an escaped quote, not a terminator
+				if i+1 < len(code) && code[i+1] == quote {
+					i++
+				} else {
+					quote = 0
+				}
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ';':
+			flush(i)
+			start = i + 1
+			startLine = line
+		}
+	}
+	flush(len(code))
+
+	return statements
+}
+
+// analyzeSQLCode analyzes SQL code, splitting multi-statement scripts so each
+// statement is checked independently with absolute line attribution
 func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []AnalysisSuggestion, error) {
 	diagnostics := []AnalysisDiagnostic{}
 	suggestions := []AnalysisSuggestion{}
 
+	for _, statement := range splitSQLStatements(code) {
+		stmtDiagnostics, stmtSuggestions, err := h.analyzeSQLStatement(statement.text)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		offset := statement.startLine - 1
+		for i := range stmtDiagnostics {
+			stmtDiagnostics[i].Line += offset
+			if stmtDiagnostics[i].EndLine > 0 {
+				stmtDiagnostics[i].EndLine += offset
+			}
+		}
+		for i := range stmtSuggestions {
+			stmtSuggestions[i].Line += offset
+			if stmtSuggestions[i].EndLine > 0 {
+				stmtSuggestions[i].EndLine += offset
+			}
+		}
+
+		diagnostics = append(diagnostics, stmtDiagnostics...)
+		suggestions = append(suggestions, stmtSuggestions...)
+	}
+
+	return diagnostics, suggestions, nil
+}
+
+// analyzeSQLStatement analyzes a single SQL statement; line numbers are
+// relative to the statement
+func (h *AnalysisHandler) analyzeSQLStatement(code string) ([]AnalysisDiagnostic, []AnalysisSuggestion, error) {
+	diagnostics := []AnalysisDiagnostic{}
+	suggestions := []AnalysisSuggestion{}
+
 	// Check for common SQL issues
-	
+
 	// Check for SELECT *
 	if strings.Contains(strings.ToUpper(code), "SELECT *") {
 		lineNum := 0
@@ -267,12 +720,13 @@ func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []A
 				break
 			}
 		}
-		
+
 		diagnostics = append(diagnostics, AnalysisDiagnostic{
 			Message:  "Using SELECT * can impact performance and may return unnecessary columns",
 			Severity: "warning",
 			Line:     lineNum,
 			Column:   strings.Index(strings.ToUpper(strings.Split(code, "\n")[lineNum-1]), "SELECT *") + 1,
+			Code:     "sql/select-star",
 		})
 		suggestions = append(suggestions, AnalysisSuggestion{
 			Message: "Specify only the columns you need",
@@ -280,9 +734,8 @@ func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []A
 			Column:  0,
 		})
 	}
-	
+
 	// Check for missing WHERE clause in UPDATE or DELETE
-	updateOrDeleteRegex := regexp.MustCompile(`(?i)(UPDATE|DELETE FROM)\s+\w+\s+(SET\s+.*\s+)?(ORDER BY|LIMIT|$)`)
 	if updateOrDeleteRegex.MatchString(code) {
 		lineNum := 0
 		for i, line := range strings.Split(code, "\n") {
@@ -291,12 +744,13 @@ func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []A
 				break
 			}
 		}
-		
+
 		diagnostics = append(diagnostics, AnalysisDiagnostic{
 			Message:  "UPDATE or DELETE without WHERE clause will affect all rows",
 			Severity: "error",
 			Line:     lineNum,
 			Column:   0,
+			Code:     "sql/missing-where",
 		})
 		suggestions = append(suggestions, AnalysisSuggestion{
 			Message: "Add a WHERE clause to limit the scope of the operation",
@@ -304,7 +758,57 @@ func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []A
 			Column:  0,
 		})
 	}
-	
+
+	// Check for correlated subqueries in the SELECT list, which tend to run
+	// once per row and usually indicate an N+1-style query
+	upperCode := strings.ToUpper(code)
+	subqueryIdx := strings.Index(upperCode, "(SELECT")
+	fromIdx := strings.Index(upperCode, "FROM")
+	if subqueryIdx != -1 && fromIdx != -1 && subqueryIdx < fromIdx {
+		lineNum := strings.Count(code[:subqueryIdx], "\n") + 1
+
+		diagnostics = append(diagnostics, AnalysisDiagnostic{
+			Message:  "Subquery in SELECT list may execute once per row",
+			Severity: "info",
+			Line:     lineNum,
+			Column:   0,
+			Code:     "sql/correlated-subquery",
+		})
+		suggestions = append(suggestions, AnalysisSuggestion{
+			Message: "Consider rewriting the correlated subquery as a JOIN",
+			Line:    lineNum,
+			Column:  0,
+		})
+	}
+
+	// Check for bare SELECTs with nothing bounding the result set. These
+	// often accidentally scan huge tables during exploration. Aggregate-only
+	// queries return a handful of rows, so they are exempt.
+	if strings.Contains(upperCode, "SELECT") && strings.Contains(upperCode, "FROM") &&
+		!strings.Contains(upperCode, "WHERE") && !strings.Contains(upperCode, "LIMIT") &&
+		!aggregateRegex.MatchString(upperCode) {
+		lineNum := 1
+		for i, line := range strings.Split(code, "\n") {
+			if strings.Contains(strings.ToUpper(line), "SELECT") {
+				lineNum = i + 1
+				break
+			}
+		}
+
+		diagnostics = append(diagnostics, AnalysisDiagnostic{
+			Message:  "SELECT without WHERE or LIMIT may scan the entire table",
+			Severity: "info",
+			Line:     lineNum,
+			Column:   0,
+			Code:     "sql/missing-limit",
+		})
+		suggestions = append(suggestions, AnalysisSuggestion{
+			Message: "Add a LIMIT clause (or a WHERE filter) to bound the result set",
+			Line:    lineNum,
+			Column:  0,
+		})
+	}
+
 	// Check for potential SQL injection
 	if strings.Contains(code, "+") && (strings.Contains(strings.ToUpper(code), "WHERE") || strings.Contains(strings.ToUpper(code), "VALUES")) {
 		lineNum := 0
@@ -314,12 +818,13 @@ func (h *AnalysisHandler) analyzeSQLCode(code string) ([]AnalysisDiagnostic, []A
 				break
 			}
 		}
-		
+
 		diagnostics = append(diagnostics, AnalysisDiagnostic{
 			Message:  "String concatenation in SQL queries can lead to SQL injection vulnerabilities",
 			Severity: "error",
 			Line:     lineNum,
 			Column:   0,
+			Code:     "sql/string-concat",
 		})
 		suggestions = append(suggestions, AnalysisSuggestion{
 			Message: "Use parameterized queries or prepared statements instead of string concatenation",
@@ -341,17 +846,20 @@ func (h *AnalysisHandler) analyzeGenericCode(code string) ([]AnalysisDiagnostic,
 	// Check for common issues in any language
 	for i, line := range lines {
 		lineNum := i + 1
-		
-		// Check for very long lines
-		if len(line) > 100 {
+
+		// Check for very long lines, highlighting the overflowing span
+		if len(line) > h.maxLineLength {
 			diagnostics = append(diagnostics, AnalysisDiagnostic{
-				Message:  "Line exceeds 100 characters which may affect readability",
+				Message:  fmt.Sprintf("Line is %d characters long, exceeding the limit of %d", len(line), h.maxLineLength),
 				Severity: "info",
 				Line:     lineNum,
-				Column:   100,
+				Column:   h.maxLineLength + 1,
+				EndLine:  lineNum,
+				EndCol:   len(line),
+				Code:     "generic/long-line",
 			})
 		}
-		
+
 		// Check for TODO comments
 		if strings.Contains(strings.ToUpper(line), "TODO") {
 			diagnostics = append(diagnostics, AnalysisDiagnostic{
@@ -359,17 +867,18 @@ func (h *AnalysisHandler) analyzeGenericCode(code string) ([]AnalysisDiagnostic,
 				Severity: "info",
 				Line:     lineNum,
 				Column:   strings.Index(strings.ToUpper(line), "TODO"),
+				Code:     "generic/todo-comment",
 			})
 		}
-		
+
 		// Check for hardcoded credentials
-		credentialRegex := regexp.MustCompile(`(?i)(password|passwd|pwd|secret|key|token|api_key|apikey)\s*[=:]\s*['"][^'"]*['"]`)
-		if credentialRegex.MatchString(line) {
+		if credentialRegex.MatchString(capLineForScan(line)) {
 			diagnostics = append(diagnostics, AnalysisDiagnostic{
 				Message:  "Potential hardcoded credential detected",
 				Severity: "warning",
 				Line:     lineNum,
 				Column:   0,
+				Code:     "generic/hardcoded-credential",
 			})
 			suggestions = append(suggestions, AnalysisSuggestion{
 				Message: "Use environment variables or a secure configuration system for sensitive information",
@@ -382,12 +891,60 @@ func (h *AnalysisHandler) analyzeGenericCode(code string) ([]AnalysisDiagnostic,
 	return diagnostics, suggestions, nil
 }
 
+// applySeverityOverrides replaces diagnostic severities with any configured per-rule overrides
+func (h *AnalysisHandler) applySeverityOverrides(diagnostics []AnalysisDiagnostic) []AnalysisDiagnostic {
+	if len(h.severityOverrides) == 0 {
+		return diagnostics
+	}
+
+	for i, diag := range diagnostics {
+		if diag.Code == "" {
+			continue
+		}
+		if severity, ok := h.severityOverrides[diag.Code]; ok {
+			diagnostics[i].Severity = severity
+		}
+	}
+
+	return diagnostics
+}
+
+// attachCodeActions links each diagnostic to the suggestion at the same
+// position, attaching its replacement as a quick-fix code action. Suggestions
+// without a replacement, or without a matching diagnostic, are left as-is.
+func attachCodeActions(diagnostics []AnalysisDiagnostic, suggestions []AnalysisSuggestion) []AnalysisDiagnostic {
+	for i, diag := range diagnostics {
+		for _, suggestion := range suggestions {
+			if suggestion.Replacement == "" {
+				continue
+			}
+			if suggestion.Line != diag.Line || suggestion.Column != diag.Column {
+				continue
+			}
+			diagnostics[i].CodeActions = append(diagnostics[i].CodeActions, CodeAction{
+				Title: suggestion.Message,
+				Edits: []CodeActionEdit{
+					{
+						Line:      suggestion.Line,
+						Column:    suggestion.Column,
+						EndLine:   suggestion.EndLine,
+						EndColumn: suggestion.EndCol,
+						NewText:   suggestion.Replacement,
+					},
+				},
+			})
+		}
+	}
+
+	return diagnostics
+}
+
 // generateSummary generates a summary of the analysis
 func (h *AnalysisHandler) generateSummary(diagnostics []AnalysisDiagnostic, suggestions []AnalysisSuggestion) string {
 	errorCount := 0
 	warningCount := 0
 	infoCount := 0
-	
+
 	for _, diag := range diagnostics {
 		switch diag.Severity {
 		case "error":
@@ -398,13 +955,13 @@ func (h *AnalysisHandler) generateSummary(diagnostics []AnalysisDiagnostic, sugg
 			infoCount++
 		}
 	}
-	
+
 	summary := fmt.Sprintf("Found %d errors, %d warnings, and %d informational issues. ", errorCount, warningCount, infoCount)
-	
+
 	if len(suggestions) > 0 {
 		summary += fmt.Sprintf("Provided %d suggestions for improvement.", len(suggestions))
 	}
-	
+
 	return summary
 }
 
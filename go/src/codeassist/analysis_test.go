@@ -0,0 +1,122 @@
+package codeassist
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// testLogger returns a quiet logger for handler tests
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// nakedReturnCode trips the go/naked-return rule, which reports at severity
+// info by default
+const nakedReturnCode = `package main
+
+func process() (result int) {
+	result = 1
+	return
+}
+`
+
+// findDiagnostic returns the first diagnostic with the given rule code
+func findDiagnostic(diagnostics []AnalysisDiagnostic, code string) (AnalysisDiagnostic, bool) {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Code == code {
+			return diagnostic, true
+		}
+	}
+	return AnalysisDiagnostic{}, false
+}
+
+func TestAnalyzeCodeReportsNakedReturnAsInfo(t *testing.T) {
+	h := NewAnalysisHandler(testLogger())
+
+	response, err := h.AnalyzeCode(&AnalysisRequest{Code: nakedReturnCode, Language: "go"})
+	if err != nil {
+		t.Fatalf("AnalyzeCode failed: %v", err)
+	}
+
+	diagnostic, ok := findDiagnostic(response.Diagnostics, "go/naked-return")
+	if !ok {
+		t.Fatalf("go/naked-return not reported: %+v", response.Diagnostics)
+	}
+	if diagnostic.Severity != "info" {
+		t.Errorf("expected default severity info, got %q", diagnostic.Severity)
+	}
+}
+
+func TestSeverityOverridePromotesRule(t *testing.T) {
+	h := NewAnalysisHandler(testLogger())
+	h.SetSeverityOverrides(map[string]string{"go/naked-return": "error"})
+
+	response, err := h.AnalyzeCode(&AnalysisRequest{Code: nakedReturnCode, Language: "go"})
+	if err != nil {
+		t.Fatalf("AnalyzeCode failed: %v", err)
+	}
+
+	diagnostic, ok := findDiagnostic(response.Diagnostics, "go/naked-return")
+	if !ok {
+		t.Fatalf("go/naked-return not reported: %+v", response.Diagnostics)
+	}
+	if diagnostic.Severity != "error" {
+		t.Errorf("override did not apply: severity %q", diagnostic.Severity)
+	}
+}
+
+func TestDisabledRuleIsSilenced(t *testing.T) {
+	h := NewAnalysisHandler(testLogger())
+	h.SetDisabledRules([]string{"go/naked-return"})
+
+	response, err := h.AnalyzeCode(&AnalysisRequest{Code: nakedReturnCode, Language: "go"})
+	if err != nil {
+		t.Fatalf("AnalyzeCode failed: %v", err)
+	}
+
+	if diagnostic, ok := findDiagnostic(response.Diagnostics, "go/naked-return"); ok {
+		t.Errorf("disabled rule still reported: %+v", diagnostic)
+	}
+}
+
+// BenchmarkAnalyzeExtremelyLongLines exercises the per-line regex rules on a
+// file whose lines far exceed maxLineScanLength, demonstrating the effect of
+// capLineForScan on pathological inputs like minified or generated code
+func BenchmarkAnalyzeExtremelyLongLines(b *testing.B) {
+	h := NewAnalysisHandler(testLogger())
+
+	line := "data = \"" + strings.Repeat("x", 100*maxLineScanLength) + "\""
+	request := &AnalysisRequest{
+		Code:     strings.Repeat(line+"\n", 50),
+		Language: "python",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.AnalyzeCode(request); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDisabledRuleDoesNotAffectOthers(t *testing.T) {
+	h := NewAnalysisHandler(testLogger())
+	h.SetDisabledRules([]string{"go/naked-return"})
+
+	// An unused import must still be reported when an unrelated rule is off
+	code := "package main\n\nimport \"os\"\n\nfunc main() {}\n"
+	response, err := h.AnalyzeCode(&AnalysisRequest{Code: code, Language: "go"})
+	if err != nil {
+		t.Fatalf("AnalyzeCode failed: %v", err)
+	}
+
+	if _, ok := findDiagnostic(response.Diagnostics, "go/unused-import"); !ok {
+		t.Errorf("go/unused-import suppressed by unrelated disable: %+v", response.Diagnostics)
+	}
+}
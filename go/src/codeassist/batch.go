@@ -0,0 +1,124 @@
+package codeassist
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/cploutarchou/mcp-servers/go/respond"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// batchAnalysisWorkers is the size of the batch analysis worker pool
+const batchAnalysisWorkers = 4
+
+// BatchAnalysisFile represents a single file in a batch analysis request
+type BatchAnalysisFile struct {
+	Path     string `json:"path,omitempty"`
+	Code     string `json:"code"`
+	Language string `json:"language"`
+}
+
+// BatchAnalysisRequest represents a batch code analysis request
+type BatchAnalysisRequest struct {
+	Files []BatchAnalysisFile `json:"files"`
+}
+
+// BatchAnalysisResult represents the analysis outcome for a single file
+type BatchAnalysisResult struct {
+	Path     string            `json:"path,omitempty"`
+	Analysis *AnalysisResponse `json:"analysis,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// BatchAnalysisResponse represents a batch code analysis response
+type BatchAnalysisResponse struct {
+	Results []BatchAnalysisResult `json:"results"`
+}
+
+// ProgressFunc is called as batch work completes, with the number of finished
+// files and the total. It is always called once more at completed == total.
+type ProgressFunc func(completed, total int)
+
+// AnalyzeBatch analyzes a set of files using a worker pool, reporting progress
+// as each file finishes
+func (h *Handler) AnalyzeBatch(request *BatchAnalysisRequest, progress ProgressFunc) (*BatchAnalysisResponse, error) {
+	total := len(request.Files)
+	h.logger.WithField("files", total).Info("Processing batch analysis request")
+
+	results := make([]BatchAnalysisResult, total)
+	jobs := make(chan int)
+
+	var mu sync.Mutex
+	completed := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < batchAnalysisWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file := request.Files[i]
+				result := BatchAnalysisResult{Path: file.Path}
+
+				analysis, err := h.analysisHandler.AnalyzeCode(&AnalysisRequest{
+					Code:     file.Code,
+					Language: file.Language,
+				})
+				if err != nil {
+					result.Error = err.Error()
+				} else {
+					result.Analysis = analysis
+				}
+				results[i] = result
+
+				mu.Lock()
+				completed++
+				if progress != nil {
+					progress(completed, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range request.Files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Guarantee a final 100% progress report even for an empty batch
+	if progress != nil && total == 0 {
+		progress(0, 0)
+	}
+
+	return &BatchAnalysisResponse{Results: results}, nil
+}
+
+// HandleBatchAnalysis handles batch code analysis requests over HTTP
+func (h *Handler) HandleBatchAnalysis(c *gin.Context) {
+	var request BatchAnalysisRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse batch analysis request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"files": len(request.Files),
+	}).Info("Received batch analysis request")
+
+	response, err := h.AnalyzeBatch(&request, nil)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to analyze batch")
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
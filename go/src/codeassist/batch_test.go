@@ -0,0 +1,75 @@
+package codeassist
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestAnalyzeBatchReportsProgress(t *testing.T) {
+	h := NewHandler(testLogger())
+
+	request := &BatchAnalysisRequest{}
+	for i := 0; i < 10; i++ {
+		request.Files = append(request.Files, BatchAnalysisFile{
+			Path:     fmt.Sprintf("file%d.go", i),
+			Code:     nakedReturnCode,
+			Language: "go",
+		})
+	}
+
+	var mu sync.Mutex
+	var calls [][2]int
+	response, err := h.AnalyzeBatch(request, func(completed, total int) {
+		mu.Lock()
+		calls = append(calls, [2]int{completed, total})
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("AnalyzeBatch failed: %v", err)
+	}
+
+	if len(response.Results) != len(request.Files) {
+		t.Fatalf("expected %d results, got %d", len(request.Files), len(response.Results))
+	}
+	// Results keep the request order regardless of worker scheduling
+	for i, result := range response.Results {
+		if result.Path != request.Files[i].Path {
+			t.Errorf("result %d has path %q, want %q", i, result.Path, request.Files[i].Path)
+		}
+		if result.Error != "" {
+			t.Errorf("result %d failed: %s", i, result.Error)
+		}
+		if result.Analysis == nil {
+			t.Errorf("result %d has no analysis", i)
+		}
+	}
+
+	// Progress is reported once per finished file, monotonically, ending at
+	// completed == total
+	if len(calls) != len(request.Files) {
+		t.Fatalf("expected %d progress calls, got %d", len(request.Files), len(calls))
+	}
+	for i, call := range calls {
+		if call[0] != i+1 || call[1] != len(request.Files) {
+			t.Errorf("progress call %d was (%d, %d), want (%d, %d)", i, call[0], call[1], i+1, len(request.Files))
+		}
+	}
+}
+
+func TestAnalyzeBatchEmptyStillReportsCompletion(t *testing.T) {
+	h := NewHandler(testLogger())
+
+	calls := 0
+	if _, err := h.AnalyzeBatch(&BatchAnalysisRequest{}, func(completed, total int) {
+		calls++
+		if completed != 0 || total != 0 {
+			t.Errorf("expected (0, 0) for an empty batch, got (%d, %d)", completed, total)
+		}
+	}); err != nil {
+		t.Fatalf("AnalyzeBatch failed: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected one final progress call, got %d", calls)
+	}
+}
@@ -0,0 +1,222 @@
+package codeassist
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LineCompletionHandler suggests whole next-line completions from
+// deterministic per-language pattern templates, complementing token-level
+// completion with idiomatic continuations (e.g. "return err" after an error
+// check)
+type LineCompletionHandler struct {
+	logger *logrus.Logger
+}
+
+// NewLineCompletionHandler creates a new line completion handler
+func NewLineCompletionHandler(logger *logrus.Logger) *LineCompletionHandler {
+	return &LineCompletionHandler{
+		logger: logger,
+	}
+}
+
+// LineCompletionRequest represents a whole-line completion request
+type LineCompletionRequest struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+	// Line is the 0-based line the cursor is on; out-of-range values fall
+	// back to the last non-empty line
+	Line int `json:"line"`
+}
+
+// LineCompletion is one suggested line, ready to insert including indentation
+type LineCompletion struct {
+	Text   string `json:"text"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// LineCompletionResponse represents a whole-line completion response, best
+// suggestion first
+type LineCompletionResponse struct {
+	Completions []LineCompletion `json:"completions"`
+}
+
+// linePattern matches a trimmed source line and yields suggested next lines.
+// Completion templates may reference the pattern's capture groups with $1,
+// $2, and so on.
+type linePattern struct {
+	pattern     *regexp.Regexp
+	completions []string
+	detail      string
+}
+
+// goLinePatterns are the Go continuation templates, most specific first
+var goLinePatterns = []linePattern{
+	{
+		pattern:     regexp.MustCompile(`^if err != nil \{$`),
+		completions: []string{"return err", `return fmt.Errorf("%w", err)`, "log.Fatal(err)"},
+		detail:      "error handling",
+	},
+	{
+		pattern:     regexp.MustCompile(`^if err := .+; err != nil \{$`),
+		completions: []string{"return err", `return fmt.Errorf("%w", err)`},
+		detail:      "error handling",
+	},
+	{
+		pattern:     regexp.MustCompile(`^for (\w+), (\w+) := range (\w+) \{$`),
+		completions: []string{"fmt.Println($1, $2)"},
+		detail:      "range loop body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^for (\w+) := range (\w+) \{$`),
+		completions: []string{"fmt.Println($2[$1])"},
+		detail:      "range loop body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^switch .*\{$`),
+		completions: []string{"default:"},
+		detail:      "switch body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^defer func\(\) \{$`),
+		completions: []string{"if r := recover(); r != nil {"},
+		detail:      "recover handler",
+	},
+}
+
+// pythonLinePatterns are the Python continuation templates
+var pythonLinePatterns = []linePattern{
+	{
+		pattern:     regexp.MustCompile(`^if __name__ == ['"]__main__['"]:$`),
+		completions: []string{"main()"},
+		detail:      "entry point",
+	},
+	{
+		pattern:     regexp.MustCompile(`^for (\w+) in (\w+):$`),
+		completions: []string{"print($1)"},
+		detail:      "loop body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^def \w+\(.*\):$`),
+		completions: []string{"pass"},
+		detail:      "function body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^class (\w+).*:$`),
+		completions: []string{"def __init__(self):"},
+		detail:      "class body",
+	},
+	{
+		pattern:     regexp.MustCompile(`^try:$`),
+		completions: []string{"pass"},
+		detail:      "try body",
+	},
+}
+
+// sqlLinePatterns are the SQL continuation templates
+var sqlLinePatterns = []linePattern{
+	{
+		pattern:     regexp.MustCompile(`(?i)^select .+ from \w+$`),
+		completions: []string{"WHERE id = ?", "ORDER BY id", "LIMIT 100"},
+		detail:      "query continuation",
+	},
+	{
+		pattern:     regexp.MustCompile(`(?i)^update (\w+)$`),
+		completions: []string{"SET"},
+		detail:      "query continuation",
+	},
+}
+
+// linePatternsFor returns the continuation templates for a language; nil
+// means the language has none
+func linePatternsFor(language string) []linePattern {
+	switch strings.ToLower(language) {
+	case "go":
+		return goLinePatterns
+	case "python":
+		return pythonLinePatterns
+	case "sql":
+		return sqlLinePatterns
+	}
+	return nil
+}
+
+// GetLineCompletions matches the cursor line against the language's
+// continuation templates and returns the suggested next lines, indented one
+// level past the matched line when it opens a block
+func (h *LineCompletionHandler) GetLineCompletions(request *LineCompletionRequest) (*LineCompletionResponse, error) {
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	h.logger.WithFields(logrus.Fields{
+		"language": request.Language,
+		"line":     request.Line,
+	}).Info("Processing line completion request")
+
+	response := &LineCompletionResponse{
+		Completions: []LineCompletion{},
+	}
+
+	line := cursorLine(request.Code, request.Line)
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return response, nil
+	}
+
+	indent := nextLineIndent(line, trimmed, request.Language)
+	for _, candidate := range linePatternsFor(request.Language) {
+		match := candidate.pattern.FindStringSubmatchIndex(trimmed)
+		if match == nil {
+			continue
+		}
+		for _, template := range candidate.completions {
+			text := string(candidate.pattern.ExpandString(nil, template, trimmed, match))
+			response.Completions = append(response.Completions, LineCompletion{
+				Text:   indent + text,
+				Detail: candidate.detail,
+			})
+		}
+		// Templates are ordered most specific first; the first match wins
+		break
+	}
+
+	return response, nil
+}
+
+// cursorLine returns the 0-based line of code the cursor is on, falling back
+// to the last non-empty line when the index is out of range
+func cursorLine(code string, index int) string {
+	lines := strings.Split(code, "\n")
+	if index >= 0 && index < len(lines) {
+		return lines[index]
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.TrimSpace(lines[i]) != "" {
+			return lines[i]
+		}
+	}
+	return ""
+}
+
+// nextLineIndent computes the indentation for a suggested next line: the
+// matched line's own indentation, one level deeper when the line opens a
+// block
+func nextLineIndent(line, trimmed, language string) string {
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	opensBlock := strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, ":")
+	if !opensBlock {
+		return indent
+	}
+	if language == "python" {
+		return indent + "    "
+	}
+	return indent + "\t"
+}
@@ -1,32 +1,389 @@
 package codeassist
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
+// completionCacheTTL is how long a cached completion result stays valid
+const completionCacheTTL = 5 * time.Second
+
+// completionCacheMaxEntries bounds the completion cache. Every cursor
+// position hashes to a distinct key while the user types, so entries are
+// evicted least-recently-used instead of waiting for a same-key lookup to
+// notice they expired.
+const completionCacheMaxEntries = 512
+
+// completionCacheWindow is the number of lines around the cursor hashed into
+// the cache key, so edits near the cursor invalidate the entry
+const completionCacheWindow = 16
+
+// ErrCodeTooLarge is returned when a completion request's code exceeds the
+// configured maximum size
+var ErrCodeTooLarge = errors.New("code too large")
+
 // CompletionHandler handles code completion requests
 type CompletionHandler struct {
-	logger *logrus.Logger
+	logger        *logrus.Logger
+	cache         *lruCache
+	cacheMu       sync.Mutex
+	cacheHits     uint64
+	cacheMisses   uint64
+	extraKeywords map[string][]string
+	maxCodeBytes  int
+	timeout       time.Duration
+	static        staticCompletionItems
+	popularity    map[string]int
+	cacheObserver CacheMetricsObserver
+}
+
+// SetCacheObserver registers a receiver for completion cache hit/miss events.
+// Call before serving.
+func (h *CompletionHandler) SetCacheObserver(observer CacheMetricsObserver) {
+	h.cacheObserver = observer
+}
+
+// staticCompletionItems holds the completion item slices that never vary
+// between requests, built once at construction (and rebuilt when extra
+// keywords are loaded) so the providers don't reallocate them per call.
+// Providers must copy before mutating or filtering.
+type staticCompletionItems struct {
+	goKeywords     []CompletionItem
+	goPackages     []CompletionItem
+	goMethods      []CompletionItem
+	pythonKeywords []CompletionItem
+	pythonModules  []CompletionItem
+	pythonMethods  []CompletionItem
+	sqlKeywords    []CompletionItem
+	sqlFunctions   []CompletionItem
+	generic        []CompletionItem
+}
+
+// SetMaxCodeBytes caps the size of code payloads accepted by GetCompletions.
+// Zero means no limit. Call before serving.
+func (h *CompletionHandler) SetMaxCodeBytes(limit int) {
+	h.maxCodeBytes = limit
+}
+
+// SetTimeout sets the default soft deadline for completion requests. When
+// providers overrun it, whatever items they have produced so far are returned
+// with Incomplete set instead of blocking the editor. Zero disables it.
+func (h *CompletionHandler) SetTimeout(timeout time.Duration) {
+	h.timeout = timeout
 }
 
 // NewCompletionHandler creates a new completion handler
 func NewCompletionHandler(logger *logrus.Logger) *CompletionHandler {
-	return &CompletionHandler{
-		logger: logger,
+	h := &CompletionHandler{
+		logger:        logger,
+		cache:         newLRUCache(completionCacheTTL, completionCacheMaxEntries),
+		extraKeywords: make(map[string][]string),
+		popularity:    make(map[string]int, len(completionPopularity)),
+	}
+	for label, weight := range completionPopularity {
+		h.popularity[label] = weight
+	}
+	h.rebuildStaticItems()
+	return h
+}
+
+// completionPopularity weights completion labels by how often they are used
+// in everyday code, on a 0-100 scale. The weights feed SortText so common
+// items outrank rare ones; anything absent from the table weighs zero.
+var completionPopularity = map[string]int{
+	// Go
+	"func": 95, "if": 90, "for": 88, "return": 85, "else": 75, "type": 72,
+	"struct": 68, "range": 66, "map": 60, "interface": 58, "case": 56,
+	"switch": 55, "defer": 50, "go": 45, "default": 40, "chan": 35,
+
+	// Python
+	"def": 95, "import": 80, "class": 70, "from": 65, "with": 60,
+	"try": 55, "except": 54, "while": 50, "elif": 48, "as": 45,
+	"lambda": 30, "yield": 25, "finally": 20,
+
+	// SQL
+	"SELECT": 95, "FROM": 90, "WHERE": 88, "AND": 80, "OR": 70,
+	"JOIN": 68, "ORDER BY": 60, "GROUP BY": 58, "INSERT INTO": 50,
+	"UPDATE": 48, "DELETE FROM": 45, "LEFT JOIN": 44, "INNER JOIN": 42,
+	"COUNT()": 70, "SUM()": 55, "MAX()": 45, "MIN()": 44, "AVG()": 40,
+
+	// Generic constructs
+	"function": 75, "const": 60, "let": 55, "var": 50,
+}
+
+// SetPopularity merges per-label popularity overrides (0-100) over the
+// built-in frequency table. Call before serving.
+func (h *CompletionHandler) SetPopularity(overrides map[string]int) {
+	for label, weight := range overrides {
+		h.popularity[label] = weight
+	}
+}
+
+// completionSortBudget is the score a SortText rank is subtracted from, so
+// higher-scoring items sort lexicographically first
+const completionSortBudget = 1000
+
+// prefixMatchBonus is added to the score of items whose label starts with
+// the typed prefix, so direct matches outrank merely-popular items
+const prefixMatchBonus = 150
+
+// applySortText ranks the items by blending the popularity weight with a
+// prefix-match bonus, encoding the rank into SortText. Ties fall back to the
+// label, keeping the order stable and alphabetical.
+func (h *CompletionHandler) applySortText(items []CompletionItem, prefix string) {
+	trimmed := strings.TrimSpace(prefix)
+	for i := range items {
+		score := h.popularity[items[i].Label]
+		if trimmed != "" && strings.HasPrefix(items[i].Label, trimmed) {
+			score += prefixMatchBonus
+		}
+		if score >= completionSortBudget {
+			score = completionSortBudget - 1
+		}
+		items[i].SortText = fmt.Sprintf("%04d_%s", completionSortBudget-score, items[i].Label)
+	}
+}
+
+// Built-in completion sources. These are turned into CompletionItem slices by
+// rebuildStaticItems, so the providers work with prebuilt items.
+var (
+	goCompletionKeywords = []string{
+		"func", "type", "struct", "interface", "map", "chan", "go", "defer", "if", "else", "for", "range", "switch", "case", "default", "return",
+	}
+	goCompletionPackages = []string{
+		"fmt", "os", "io", "net/http", "encoding/json", "strings", "time", "context", "errors",
+	}
+	goCompletionMethods = []string{
+		"String()", "Error()", "Close()", "Read()", "Write()", "Marshal()", "Unmarshal()", "Print()", "Println()", "Printf()",
+	}
+	pythonCompletionKeywords = []string{
+		"def", "class", "if", "else", "elif", "for", "while", "try", "except", "finally", "with", "import", "from", "as", "return", "yield", "lambda",
+	}
+	pythonCompletionModules = []string{
+		"os", "sys", "json", "datetime", "math", "random", "re", "collections", "itertools", "functools",
+	}
+	pythonCompletionMethods = []string{
+		"__init__", "__str__", "__repr__", "append()", "extend()", "pop()", "keys()", "values()", "items()", "get()", "update()",
+	}
+	sqlCompletionKeywords = []string{
+		"SELECT", "FROM", "WHERE", "JOIN", "LEFT JOIN", "RIGHT JOIN", "INNER JOIN", "GROUP BY", "ORDER BY", "HAVING",
+		"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "CREATE TABLE", "ALTER TABLE", "DROP TABLE",
+		"AND", "OR", "NOT", "IN", "BETWEEN", "LIKE", "IS NULL", "IS NOT NULL",
+	}
+	sqlCompletionFunctions = []string{
+		"COUNT()", "SUM()", "AVG()", "MIN()", "MAX()", "COALESCE()", "NULLIF()", "CAST()", "CONVERT()",
+		"UPPER()", "LOWER()", "TRIM()", "SUBSTRING()", "CONCAT()", "LENGTH()", "ROUND()", "NOW()", "CURRENT_DATE()",
+	}
+	genericCompletionConstructs = []string{
+		"if", "else", "for", "while", "function", "class", "return", "var", "let", "const",
+	}
+)
+
+// completionItemsFor builds the CompletionItem slice for a list of labels
+// sharing a kind, detail, and documentation format
+func completionItemsFor(labels []string, kind, detail, docFormat string) []CompletionItem {
+	items := make([]CompletionItem, 0, len(labels))
+	for _, label := range labels {
+		items = append(items, CompletionItem{
+			Label:         label,
+			Kind:          kind,
+			Detail:        detail,
+			Documentation: fmt.Sprintf(docFormat, label),
+			InsertText:    label,
+		})
+	}
+	return items
+}
+
+// rebuildStaticItems regenerates the precomputed completion item slices,
+// folding in any extra keywords loaded for the built-in languages
+func (h *CompletionHandler) rebuildStaticItems() {
+	h.static = staticCompletionItems{
+		goKeywords:     completionItemsFor(h.keywordsFor("go", goCompletionKeywords), "keyword", "Go keyword", "Go keyword: %s"),
+		goPackages:     completionItemsFor(goCompletionPackages, "module", "Go package", "Standard library package: %s"),
+		goMethods:      completionItemsFor(goCompletionMethods, "method", "Go method", "Common Go method: %s"),
+		pythonKeywords: completionItemsFor(h.keywordsFor("python", pythonCompletionKeywords), "keyword", "Python keyword", "Python keyword: %s"),
+		pythonModules:  completionItemsFor(pythonCompletionModules, "module", "Python module", "Standard library module: %s"),
+		pythonMethods:  completionItemsFor(pythonCompletionMethods, "method", "Python method", "Common Python method: %s"),
+		sqlKeywords:    completionItemsFor(h.keywordsFor("sql", sqlCompletionKeywords), "keyword", "SQL keyword", "SQL keyword: %s"),
+		sqlFunctions:   completionItemsFor(sqlCompletionFunctions, "function", "SQL function", "SQL function: %s"),
+		generic:        completionItemsFor(genericCompletionConstructs, "keyword", "Programming construct", "Common programming construct: %s"),
+	}
+}
+
+// LoadKeywords loads extra per-language completion keywords from a JSON or
+// YAML file and merges them over the built-in sets. Call before serving.
+func (h *CompletionHandler) LoadKeywords(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read completion keywords %s: %w", path, err)
+	}
+
+	keywords := map[string][]string{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &keywords); err != nil {
+			return fmt.Errorf("failed to parse completion keywords %s: %w", path, err)
+		}
+	default:
+		if err := json.Unmarshal(data, &keywords); err != nil {
+			return fmt.Errorf("failed to parse completion keywords %s: %w", path, err)
+		}
+	}
+
+	for language, words := range keywords {
+		language = NormalizeLanguage(language)
+		h.extraKeywords[language] = append(h.extraKeywords[language], words...)
+	}
+
+	h.rebuildStaticItems()
+	h.logger.WithField("source", path).Info("Loaded completion keywords")
+	return nil
+}
+
+// keywordsFor merges any configured extra keywords for a language over the
+// built-in set, skipping duplicates
+func (h *CompletionHandler) keywordsFor(language string, builtin []string) []string {
+	extra := h.extraKeywords[NormalizeLanguage(language)]
+	if len(extra) == 0 {
+		return builtin
+	}
+
+	seen := make(map[string]bool, len(builtin))
+	for _, keyword := range builtin {
+		seen[keyword] = true
+	}
+
+	merged := builtin
+	for _, keyword := range extra {
+		if !seen[keyword] {
+			seen[keyword] = true
+			merged = append(merged, keyword)
+		}
+	}
+
+	return merged
+}
+
+// cacheKey hashes the language, the code window around the cursor, and the
+// cursor position into a cache key
+func completionCacheKey(request *CompletionRequest) string {
+	lines := strings.Split(request.Code, "\n")
+	start := request.Line - completionCacheWindow
+	if start < 0 {
+		start = 0
+	}
+	end := request.Line + completionCacheWindow
+	if end > len(lines) {
+		end = len(lines)
+	}
+	window := ""
+	if start < end {
+		window = strings.Join(lines[start:end], "\n")
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%d\x00%s", request.Language, window, request.Line, request.Column, request.TriggerKind, request.TriggerCharacter)))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachedCompletions returns a cached response for the request, if still valid
+func (h *CompletionHandler) cachedCompletions(key string) (*CompletionResponse, bool) {
+	value, ok := h.cache.get(key)
+
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	if !ok {
+		h.cacheMisses++
+		if h.cacheObserver != nil {
+			h.cacheObserver.CacheMiss("completion")
+		}
+		return nil, false
 	}
+
+	h.cacheHits++
+	if h.cacheObserver != nil {
+		h.cacheObserver.CacheHit("completion")
+	}
+	return value.(*CompletionResponse), true
+}
+
+// storeCompletions caches a response for the short TTL
+func (h *CompletionHandler) storeCompletions(key string, response *CompletionResponse) {
+	h.cache.set(key, response)
+}
+
+// CacheSize returns the number of entries currently cached
+func (h *CompletionHandler) CacheSize() int {
+	return h.cache.len()
 }
 
+// CacheStats returns the completion cache hit and miss counts
+func (h *CompletionHandler) CacheStats() (hits, misses uint64) {
+	h.cacheMu.Lock()
+	defer h.cacheMu.Unlock()
+
+	return h.cacheHits, h.cacheMisses
+}
+
+// LSP-style completion trigger kinds
+const (
+	triggerKindInvoked          = 1
+	triggerKindTriggerCharacter = 2
+)
+
 // CompletionRequest represents a code completion request
 type CompletionRequest struct {
 	Code     string `json:"code"`
 	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
 	Line     int    `json:"line"`
 	Column   int    `json:"column"`
 	Context  string `json:"context,omitempty"`
+	Compact  bool   `json:"compact,omitempty"`
+
+	// TriggerKind and TriggerCharacter mirror the LSP completion context: 1
+	// for a manual invoke, 2 for a trigger character, 3 for a re-request of
+	// an incomplete list. Zero means no context was sent and behavior is
+	// unchanged.
+	TriggerKind      int    `json:"triggerKind,omitempty"`
+	TriggerCharacter string `json:"triggerCharacter,omitempty"`
+
+	// TimeoutMs overrides the server's completion soft deadline for this
+	// request. Zero keeps the configured default.
+	TimeoutMs int `json:"timeoutMs,omitempty"`
+
+	// Seq is a client-supplied monotonically increasing sequence number used
+	// to debounce rapid typing: the MCP layer drops any request whose seq is
+	// older than the latest seen for the session. Zero disables the check.
+	Seq int64 `json:"seq,omitempty"`
+
+	// Shape selects the response encoding: "lsp" returns an LSP
+	// CompletionList (isIncomplete plus numeric item kinds) so LSP bridges
+	// need no translation layer. Empty keeps the native shape.
+	Shape string `json:"shape,omitempty"`
+}
+
+// memberTriggered reports whether the client asked for member completions by
+// typing the '.' trigger character
+func (r *CompletionRequest) memberTriggered() bool {
+	return r.TriggerKind == triggerKindTriggerCharacter && r.TriggerCharacter == "."
+}
+
+// manuallyInvoked reports whether the client explicitly invoked completion
+func (r *CompletionRequest) manuallyInvoked() bool {
+	return r.TriggerKind == triggerKindInvoked
 }
 
 // CompletionItem represents a single completion suggestion
@@ -42,6 +399,76 @@ type CompletionItem struct {
 // CompletionResponse represents a code completion response
 type CompletionResponse struct {
 	Items []CompletionItem `json:"items"`
+	// Incomplete reports that providers were cut off by the soft deadline
+	// and the item list may be missing candidates
+	Incomplete bool `json:"incomplete,omitempty"`
+}
+
+// CompactCompletionResponse mirrors CompletionResponse but omits empty
+// fields, for clients on slow links that request compact output
+type CompactCompletionResponse struct {
+	Items      []CompletionItem `json:"items,omitempty"`
+	Incomplete bool             `json:"incomplete,omitempty"`
+}
+
+// Compact returns a view of the response with empty fields omitted
+func (r *CompletionResponse) Compact() *CompactCompletionResponse {
+	return &CompactCompletionResponse{Items: r.Items, Incomplete: r.Incomplete}
+}
+
+// ShapeLSP is the request shape selecting the LSP CompletionList encoding
+const ShapeLSP = "lsp"
+
+// LSPCompletionItem is a completion item using LSP field names and the
+// numeric CompletionItemKind encoding
+type LSPCompletionItem struct {
+	Label         string `json:"label"`
+	Kind          int    `json:"kind,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+	InsertText    string `json:"insertText,omitempty"`
+	SortText      string `json:"sortText,omitempty"`
+}
+
+// LSPCompletionList mirrors the LSP CompletionList shape: isIncomplete at the
+// top level and items with numeric kinds
+type LSPCompletionList struct {
+	IsIncomplete bool                `json:"isIncomplete"`
+	Items        []LSPCompletionItem `json:"items"`
+}
+
+// lspCompletionKinds maps the server's item kind names to LSP
+// CompletionItemKind values; unmapped kinds fall back to Text (1)
+var lspCompletionKinds = map[string]int{
+	"text":     1,
+	"method":   2,
+	"function": 3,
+	"field":    5,
+	"variable": 6,
+	"class":    7,
+	"module":   9,
+	"keyword":  14,
+	"snippet":  15,
+}
+
+// LSP returns the response converted to the LSP CompletionList shape
+func (r *CompletionResponse) LSP() *LSPCompletionList {
+	items := make([]LSPCompletionItem, 0, len(r.Items))
+	for _, item := range r.Items {
+		kind, ok := lspCompletionKinds[item.Kind]
+		if !ok {
+			kind = 1
+		}
+		items = append(items, LSPCompletionItem{
+			Label:         item.Label,
+			Kind:          kind,
+			Detail:        item.Detail,
+			Documentation: item.Documentation,
+			InsertText:    item.InsertText,
+			SortText:      item.SortText,
+		})
+	}
+	return &LSPCompletionList{IsIncomplete: r.Incomplete, Items: items}
 }
 
 // GetCompletions returns code completion suggestions
@@ -52,6 +479,27 @@ func (h *CompletionHandler) GetCompletions(request *CompletionRequest) (*Complet
 		"column":   request.Column,
 	}).Info("Processing completion request")
 
+	// Reject oversized payloads before any per-line work; this is a
+	// domain-specific limit distinct from the transport's body limit
+	if h.maxCodeBytes > 0 && len(request.Code) > h.maxCodeBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrCodeTooLarge, len(request.Code), h.maxCodeBytes)
+	}
+
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	// Serve repeated identical requests from the short-lived cache
+	key := completionCacheKey(request)
+	if response, ok := h.cachedCompletions(key); ok {
+		h.logger.Debug("Completion cache hit")
+		return response, nil
+	}
+
 	// Extract context from the code
 	lines := strings.Split(request.Code, "\n")
 	if request.Line >= len(lines) {
@@ -66,147 +514,298 @@ func (h *CompletionHandler) GetCompletions(request *CompletionRequest) (*Complet
 	prefix := currentLine[:request.Column]
 	h.logger.WithField("prefix", prefix).Debug("Completion prefix")
 
-	// Generate completions based on language
-	var items []CompletionItem
-	var err error
-
+	// Assemble the provider stages: the language-specific provider first,
+	// then the generic construct set layered beneath it. The dedupe below
+	// keeps the language-specific entry when labels collide, making the
+	// merge order deterministic.
+	var providers []completionProvider
 	switch strings.ToLower(request.Language) {
 	case "go":
-		items, err = h.getGoCompletions(request, prefix)
+		providers = []completionProvider{h.getGoCompletions, h.getGenericCompletions}
 	case "python":
-		items, err = h.getPythonCompletions(request, prefix)
+		providers = []completionProvider{h.getPythonCompletions, h.getGenericCompletions}
 	case "sql":
-		items, err = h.getSQLCompletions(request, prefix)
+		providers = []completionProvider{h.getSQLCompletions, h.getGenericCompletions}
 	default:
-		items, err = h.getGenericCompletions(request, prefix)
+		providers = []completionProvider{h.getGenericCompletions}
 	}
 
+	items, incomplete, err := h.collectCompletions(providers, request, prefix)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CompletionResponse{Items: items}, nil
+	// Collapse duplicate candidates contributed by overlapping branches
+	items = dedupeCompletionItems(items)
+
+	// Rank common items above rare ones via SortText
+	h.applySortText(items, prefix)
+
+	response := &CompletionResponse{Items: items, Incomplete: incomplete}
+
+	// A partial result is not representative of the request; don't let it
+	// shadow a later complete one
+	if !incomplete {
+		h.storeCompletions(key, response)
+	}
+
+	return response, nil
 }
 
-// getGoCompletions returns Go-specific completions
-func (h *CompletionHandler) getGoCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
-	// Basic Go keywords and common patterns
-	keywords := []string{
-		"func", "type", "struct", "interface", "map", "chan", "go", "defer", "if", "else", "for", "range", "switch", "case", "default", "return",
+// GetQuickCompletions returns only the cheap keyword-based completions for a
+// request, skipping the slower providers. It backs the first event of the
+// streaming completion mode, so the result is always marked incomplete: the
+// refined provider-backed result follows.
+func (h *CompletionHandler) GetQuickCompletions(request *CompletionRequest) (*CompletionResponse, error) {
+	if h.maxCodeBytes > 0 && len(request.Code) > h.maxCodeBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrCodeTooLarge, len(request.Code), h.maxCodeBytes)
 	}
 
-	// Common Go packages
-	packages := []string{
-		"fmt", "os", "io", "net/http", "encoding/json", "strings", "time", "context", "errors",
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
 	}
+	request.Language = NormalizeLanguage(request.Language)
 
-	// Common Go methods and functions
-	methods := []string{
-		"String()", "Error()", "Close()", "Read()", "Write()", "Marshal()", "Unmarshal()", "Print()", "Println()", "Printf()",
+	lines := strings.Split(request.Code, "\n")
+	if request.Line >= len(lines) {
+		return &CompletionResponse{Items: []CompletionItem{}, Incomplete: true}, nil
+	}
+	currentLine := lines[request.Line]
+	if request.Column > len(currentLine) {
+		request.Column = len(currentLine)
 	}
+	prefix := currentLine[:request.Column]
 
-	items := []CompletionItem{}
+	var source []CompletionItem
+	switch strings.ToLower(request.Language) {
+	case "go":
+		source = h.static.goKeywords
+	case "python":
+		source = h.static.pythonKeywords
+	case "sql":
+		source = h.static.sqlKeywords
+	default:
+		source = h.static.generic
+	}
 
-	// Check if we're importing a package
-	if strings.Contains(prefix, "import") || strings.Contains(prefix, "\"") {
-		for _, pkg := range packages {
-			items = append(items, CompletionItem{
-				Label:         pkg,
-				Kind:          "module",
-				Detail:        "Go package",
-				Documentation: fmt.Sprintf("Standard library package: %s", pkg),
-				InsertText:    pkg,
-			})
+	items := filterByLabelPrefix(source, prefix)
+	h.applySortText(items, prefix)
+	return &CompletionResponse{Items: items, Incomplete: true}, nil
+}
+
+// completionProvider produces one stage of completion candidates
+type completionProvider func(request *CompletionRequest, prefix string) ([]CompletionItem, error)
+
+// completionBatch carries one provider stage's output to the collector
+type completionBatch struct {
+	items []CompletionItem
+	err   error
+}
+
+// collectCompletions runs the provider stages in order, gathering their items
+// under the soft deadline. When the deadline passes before every stage has
+// finished, the items collected so far are returned with incomplete set, so a
+// slow provider never blocks the editor.
+func (h *CompletionHandler) collectCompletions(providers []completionProvider, request *CompletionRequest, prefix string) ([]CompletionItem, bool, error) {
+	timeout := h.timeout
+	if request.TimeoutMs > 0 {
+		timeout = time.Duration(request.TimeoutMs) * time.Millisecond
+	}
+
+	// Without a deadline, run the stages inline
+	if timeout <= 0 {
+		var items []CompletionItem
+		for _, provider := range providers {
+			batch, err := provider(request, prefix)
+			if err != nil {
+				return nil, false, err
+			}
+			items = append(items, batch...)
+		}
+		return items, false, nil
+	}
+
+	// The buffer covers every stage, so an abandoned run never leaks its
+	// goroutine on send
+	batches := make(chan completionBatch, len(providers))
+	go func() {
+		for _, provider := range providers {
+			items, err := provider(request, prefix)
+			batches <- completionBatch{items: items, err: err}
+			if err != nil {
+				return
+			}
+		}
+		close(batches)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var items []CompletionItem
+	for {
+		select {
+		case batch, ok := <-batches:
+			if !ok {
+				return items, false, nil
+			}
+			if batch.err != nil {
+				return nil, false, batch.err
+			}
+			items = append(items, batch.items...)
+		case <-timer.C:
+			h.logger.WithField("timeoutMs", timeout.Milliseconds()).Warn("Completion deadline exceeded; returning partial results")
+			return items, true, nil
 		}
-		return items, nil
-	}
-
-	// Check if we're typing a keyword
-	for _, keyword := range keywords {
-		if strings.HasPrefix(keyword, strings.TrimSpace(prefix)) || prefix == "" {
-			items = append(items, CompletionItem{
-				Label:         keyword,
-				Kind:          "keyword",
-				Detail:        "Go keyword",
-				Documentation: fmt.Sprintf("Go keyword: %s", keyword),
-				InsertText:    keyword,
-			})
+	}
+}
+
+// dedupeCompletionItems collapses items sharing the same label and kind,
+// keeping the first occurrence but preferring the richest documentation and
+// detail among the duplicates. Order is otherwise preserved.
+func dedupeCompletionItems(items []CompletionItem) []CompletionItem {
+	seen := make(map[string]int, len(items))
+	deduped := items[:0]
+
+	for _, item := range items {
+		key := item.Label + "\x00" + item.Kind
+		if index, ok := seen[key]; ok {
+			kept := &deduped[index]
+			if len(item.Documentation) > len(kept.Documentation) {
+				kept.Documentation = item.Documentation
+			}
+			if len(item.Detail) > len(kept.Detail) {
+				kept.Detail = item.Detail
+			}
+			continue
 		}
+		seen[key] = len(deduped)
+		deduped = append(deduped, item)
+	}
+
+	return deduped
+}
+
+// goStdlibMembers maps popular standard-library packages to their most-used
+// exported symbols, surfaced as member completions after "pkg."
+var goStdlibMembers = map[string][]string{
+	"fmt":     {"Println", "Printf", "Sprintf", "Errorf", "Fprintf", "Fprintln", "Sprint", "Sscanf"},
+	"os":      {"Open", "Create", "ReadFile", "WriteFile", "Getenv", "Setenv", "Exit", "Remove", "Stat", "MkdirAll"},
+	"io":      {"Copy", "ReadAll", "WriteString", "EOF"},
+	"strings": {"Contains", "Split", "Join", "ReplaceAll", "TrimSpace", "HasPrefix", "HasSuffix", "ToLower", "ToUpper", "Index", "Fields", "Builder"},
+	"strconv": {"Atoi", "Itoa", "ParseInt", "ParseFloat", "FormatInt", "Quote"},
+	"time":    {"Now", "Since", "Sleep", "Parse", "ParseDuration", "After", "NewTimer", "NewTicker", "Duration"},
+	"errors":  {"New", "Is", "As", "Unwrap", "Join"},
+	"json":    {"Marshal", "Unmarshal", "MarshalIndent", "NewEncoder", "NewDecoder", "Valid"},
+	"http":    {"Get", "Post", "NewRequest", "ListenAndServe", "HandleFunc", "Error", "StatusText"},
+	"context": {"Background", "TODO", "WithCancel", "WithTimeout", "WithDeadline", "WithValue"},
+	"sort":    {"Strings", "Ints", "Slice", "SliceStable", "SearchStrings"},
+	"bytes":   {"NewBuffer", "Contains", "Equal", "Join", "Split", "TrimSpace"},
+}
+
+// getGoCompletions returns Go-specific completions
+func (h *CompletionHandler) getGoCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
+	items := []CompletionItem{}
+
+	// Check if we're importing a package
+	if !request.memberTriggered() && (strings.Contains(prefix, "import") || strings.Contains(prefix, "\"")) {
+		return h.static.goPackages, nil
 	}
 
-	// Check if we're calling a method
-	if strings.Contains(prefix, ".") {
-		for _, method := range methods {
-			items = append(items, CompletionItem{
-				Label:         method,
-				Kind:          "method",
-				Detail:        "Go method",
-				Documentation: fmt.Sprintf("Common Go method: %s", method),
-				InsertText:    method,
-			})
+	// Check if we're typing a keyword; a '.' trigger asks for members only
+	if !request.memberTriggered() {
+		items = append(items, filterByLabelPrefix(h.static.goKeywords, prefix)...)
+	}
+
+	// Check if we're calling a method; a manual invoke asks for keywords only
+	if !request.manuallyInvoked() && (request.memberTriggered() || strings.Contains(prefix, ".")) {
+		// Prefer the fields and methods of a struct declared in this file
+		// when the receiver's type can be resolved
+		if varName := trailingIdentifier(strings.TrimSuffix(strings.TrimSpace(prefix), ".")); varName != "" {
+			if members := goStructMembers(request.Code, varName); len(members) > 0 {
+				for _, member := range members {
+					items = append(items, CompletionItem{
+						Label:         member.Name,
+						Kind:          member.Kind,
+						Detail:        member.Detail,
+						Documentation: fmt.Sprintf("Member of %s", varName),
+						InsertText:    member.Name,
+					})
+				}
+				return items, nil
+			}
+
+			// A known stdlib package gets its curated exported symbols
+			// instead of the generic method list
+			if symbols, ok := goStdlibMembers[varName]; ok {
+				for _, symbol := range symbols {
+					items = append(items, CompletionItem{
+						Label:         symbol,
+						Kind:          "function",
+						Detail:        fmt.Sprintf("%s.%s", varName, symbol),
+						Documentation: fmt.Sprintf("Exported symbol of package %s", varName),
+						InsertText:    symbol,
+					})
+				}
+				return items, nil
+			}
 		}
+
+		items = append(items, h.static.goMethods...)
 	}
 
 	return items, nil
 }
 
-// getPythonCompletions returns Python-specific completions
-func (h *CompletionHandler) getPythonCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
-	// Basic Python keywords and common patterns
-	keywords := []string{
-		"def", "class", "if", "else", "elif", "for", "while", "try", "except", "finally", "with", "import", "from", "as", "return", "yield", "lambda",
+// filterByLabelPrefix returns the items whose label starts with the trimmed
+// prefix, copying into a fresh slice so the static sets stay untouched. An
+// empty prefix matches everything.
+func filterByLabelPrefix(items []CompletionItem, prefix string) []CompletionItem {
+	trimmed := strings.TrimSpace(prefix)
+	matched := make([]CompletionItem, 0, len(items))
+	for _, item := range items {
+		if strings.HasPrefix(item.Label, trimmed) || prefix == "" {
+			matched = append(matched, item)
+		}
 	}
+	return matched
+}
 
-	// Common Python modules
-	modules := []string{
-		"os", "sys", "json", "datetime", "math", "random", "re", "collections", "itertools", "functools",
+// trailingIdentifier returns the identifier ending the string, if any
+func trailingIdentifier(s string) string {
+	end := len(s)
+	start := end
+	for start > 0 {
+		c := s[start-1]
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			start--
+			continue
+		}
+		break
 	}
-
-	// Common Python methods and functions
-	methods := []string{
-		"__init__", "__str__", "__repr__", "append()", "extend()", "pop()", "keys()", "values()", "items()", "get()", "update()",
+	if start == end {
+		return ""
 	}
+	return s[start:end]
+}
 
+// getPythonCompletions returns Python-specific completions
+func (h *CompletionHandler) getPythonCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
 	items := []CompletionItem{}
 
 	// Check if we're importing a module
-	if strings.Contains(prefix, "import") || strings.Contains(prefix, "from") {
-		for _, module := range modules {
-			items = append(items, CompletionItem{
-				Label:         module,
-				Kind:          "module",
-				Detail:        "Python module",
-				Documentation: fmt.Sprintf("Standard library module: %s", module),
-				InsertText:    module,
-			})
-		}
-		return items, nil
-	}
-
-	// Check if we're typing a keyword
-	for _, keyword := range keywords {
-		if strings.HasPrefix(keyword, strings.TrimSpace(prefix)) || prefix == "" {
-			items = append(items, CompletionItem{
-				Label:         keyword,
-				Kind:          "keyword",
-				Detail:        "Python keyword",
-				Documentation: fmt.Sprintf("Python keyword: %s", keyword),
-				InsertText:    keyword,
-			})
-		}
+	if !request.memberTriggered() && (strings.Contains(prefix, "import") || strings.Contains(prefix, "from")) {
+		return h.static.pythonModules, nil
 	}
 
-	// Check if we're calling a method
-	if strings.Contains(prefix, ".") {
-		for _, method := range methods {
-			items = append(items, CompletionItem{
-				Label:         method,
-				Kind:          "method",
-				Detail:        "Python method",
-				Documentation: fmt.Sprintf("Common Python method: %s", method),
-				InsertText:    method,
-			})
-		}
+	// Check if we're typing a keyword; a '.' trigger asks for members only
+	if !request.memberTriggered() {
+		items = append(items, filterByLabelPrefix(h.static.pythonKeywords, prefix)...)
+	}
+
+	// Check if we're calling a method; a manual invoke asks for keywords only
+	if !request.manuallyInvoked() && (request.memberTriggered() || strings.Contains(prefix, ".")) {
+		items = append(items, h.static.pythonMethods...)
 	}
 
 	return items, nil
@@ -214,70 +813,33 @@ func (h *CompletionHandler) getPythonCompletions(request *CompletionRequest, pre
 
 // getSQLCompletions returns SQL-specific completions
 func (h *CompletionHandler) getSQLCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
-	// SQL keywords
-	keywords := []string{
-		"SELECT", "FROM", "WHERE", "JOIN", "LEFT JOIN", "RIGHT JOIN", "INNER JOIN", "GROUP BY", "ORDER BY", "HAVING",
-		"INSERT INTO", "VALUES", "UPDATE", "SET", "DELETE FROM", "CREATE TABLE", "ALTER TABLE", "DROP TABLE",
-		"AND", "OR", "NOT", "IN", "BETWEEN", "LIKE", "IS NULL", "IS NOT NULL",
-	}
-
-	// SQL functions
-	functions := []string{
-		"COUNT()", "SUM()", "AVG()", "MIN()", "MAX()", "COALESCE()", "NULLIF()", "CAST()", "CONVERT()",
-		"UPPER()", "LOWER()", "TRIM()", "SUBSTRING()", "CONCAT()", "LENGTH()", "ROUND()", "NOW()", "CURRENT_DATE()",
-	}
-
 	items := []CompletionItem{}
 
-	// Check if we're typing a keyword
-	for _, keyword := range keywords {
-		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(prefix)), strings.Split(keyword, " ")[0]) || prefix == "" {
-			items = append(items, CompletionItem{
-				Label:         keyword,
-				Kind:          "keyword",
-				Detail:        "SQL keyword",
-				Documentation: fmt.Sprintf("SQL keyword: %s", keyword),
-				InsertText:    keyword,
-			})
+	// Check if we're typing a keyword; multi-word keywords match on their
+	// first word
+	upperPrefix := strings.ToUpper(strings.TrimSpace(prefix))
+	for _, item := range h.static.sqlKeywords {
+		if strings.HasPrefix(upperPrefix, strings.Split(item.Label, " ")[0]) || prefix == "" {
+			items = append(items, item)
 		}
 	}
 
 	// Check if we might be using a function
-	for _, function := range functions {
-		items = append(items, CompletionItem{
-			Label:         function,
-			Kind:          "function",
-			Detail:        "SQL function",
-			Documentation: fmt.Sprintf("SQL function: %s", function),
-			InsertText:    function,
-		})
-	}
+	items = append(items, h.static.sqlFunctions...)
 
 	return items, nil
 }
 
 // getGenericCompletions returns generic completions for unsupported languages
 func (h *CompletionHandler) getGenericCompletions(request *CompletionRequest, prefix string) ([]CompletionItem, error) {
-	// Generic programming constructs
-	constructs := []string{
-		"if", "else", "for", "while", "function", "class", "return", "var", "let", "const",
+	// Languages with configured extra keywords rebuild the construct set;
+	// everything else reuses the precomputed items
+	constructs := h.static.generic
+	if len(h.extraKeywords[NormalizeLanguage(request.Language)]) > 0 {
+		constructs = completionItemsFor(h.keywordsFor(request.Language, genericCompletionConstructs), "keyword", "Programming construct", "Common programming construct: %s")
 	}
 
-	items := []CompletionItem{}
-
-	for _, construct := range constructs {
-		if strings.HasPrefix(construct, strings.TrimSpace(prefix)) || prefix == "" {
-			items = append(items, CompletionItem{
-				Label:         construct,
-				Kind:          "keyword",
-				Detail:        "Programming construct",
-				Documentation: fmt.Sprintf("Common programming construct: %s", construct),
-				InsertText:    construct,
-			})
-		}
-	}
-
-	return items, nil
+	return filterByLabelPrefix(constructs, prefix), nil
 }
 
 // ToJSON converts the completion response to JSON
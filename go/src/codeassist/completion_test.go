@@ -0,0 +1,117 @@
+package codeassist
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkGoCompletions measures the Go provider path; the shared static
+// item slices should keep per-request allocations low
+func BenchmarkGoCompletions(b *testing.B) {
+	h := NewCompletionHandler(testLogger())
+	request := &CompletionRequest{
+		Code:     "package main\n\nfunc main() {\n\tf\n}\n",
+		Language: "go",
+		Line:     3,
+		Column:   2,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.getGoCompletions(request, "\tf"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSQLCompletions measures the SQL provider path
+func BenchmarkSQLCompletions(b *testing.B) {
+	h := NewCompletionHandler(testLogger())
+	request := &CompletionRequest{
+		Code:     "SELECT",
+		Language: "sql",
+		Line:     0,
+		Column:   6,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := h.getSQLCompletions(request, "SELECT"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestApplySortTextRanksPopularItemsFirst(t *testing.T) {
+	h := NewCompletionHandler(testLogger())
+
+	items := []CompletionItem{{Label: "chan"}, {Label: "func"}}
+	h.applySortText(items, "")
+
+	// func is weighted as far more popular than chan, so it must sort first
+	if items[1].SortText >= items[0].SortText {
+		t.Errorf("func did not outrank chan: %q vs %q", items[1].SortText, items[0].SortText)
+	}
+}
+
+func TestApplySortTextPrefixMatchOutranksPopularity(t *testing.T) {
+	h := NewCompletionHandler(testLogger())
+
+	items := []CompletionItem{{Label: "func"}, {Label: "for"}}
+	h.applySortText(items, "fo")
+
+	// The typed prefix matches for, which must outrank the more popular func
+	if items[1].SortText >= items[0].SortText {
+		t.Errorf("prefix match did not outrank popularity: %q vs %q", items[1].SortText, items[0].SortText)
+	}
+}
+
+func TestSetPopularityOverridesWeights(t *testing.T) {
+	h := NewCompletionHandler(testLogger())
+	h.SetPopularity(map[string]int{"chan": 900})
+
+	items := []CompletionItem{{Label: "chan"}, {Label: "func"}}
+	h.applySortText(items, "")
+
+	if items[0].SortText >= items[1].SortText {
+		t.Errorf("override did not promote chan: %q vs %q", items[0].SortText, items[1].SortText)
+	}
+}
+
+func TestApplySortTextTiesStayAlphabetical(t *testing.T) {
+	h := NewCompletionHandler(testLogger())
+
+	// Two labels with no popularity weight tie on score; the label suffix
+	// keeps the order stable and alphabetical
+	items := []CompletionItem{{Label: "beta"}, {Label: "alpha"}}
+	h.applySortText(items, "")
+
+	if items[1].SortText >= items[0].SortText {
+		t.Errorf("tie not broken alphabetically: %q vs %q", items[1].SortText, items[0].SortText)
+	}
+}
+
+func TestCompletionCacheIsBounded(t *testing.T) {
+	h := NewCompletionHandler(testLogger())
+
+	// Every cursor position produces a distinct key; the cache must stay
+	// bounded no matter how many are stored
+	response := &CompletionResponse{}
+	for i := 0; i < completionCacheMaxEntries+100; i++ {
+		h.storeCompletions(fmt.Sprintf("key-%d", i), response)
+	}
+
+	if size := h.CacheSize(); size != completionCacheMaxEntries {
+		t.Fatalf("expected cache capped at %d entries, got %d", completionCacheMaxEntries, size)
+	}
+
+	// The oldest keys were evicted, the newest survive
+	if _, ok := h.cachedCompletions("key-0"); ok {
+		t.Error("least-recently-used entry was not evicted")
+	}
+	if _, ok := h.cachedCompletions(fmt.Sprintf("key-%d", completionCacheMaxEntries+99)); !ok {
+		t.Error("most recent entry was evicted")
+	}
+}
@@ -3,28 +3,128 @@ package codeassist
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
+// docCacheTTL is how long a cached documentation lookup stays valid
+const docCacheTTL = 5 * time.Minute
+
+// defaultDocCacheMaxEntries bounds the documentation cache so it cannot grow
+// without limit when many distinct symbols are queried
+const defaultDocCacheMaxEntries = 512
+
 // DocumentationHandler handles documentation requests
 type DocumentationHandler struct {
-	logger *logrus.Logger
+	logger        *logrus.Logger
+	customDocs    map[string]map[string]DocResponse
+	cache         *lruCache
+	cacheObserver CacheMetricsObserver
+	flight        *singleflight
 }
 
 // NewDocumentationHandler creates a new documentation handler
 func NewDocumentationHandler(logger *logrus.Logger) *DocumentationHandler {
 	return &DocumentationHandler{
-		logger: logger,
+		logger:     logger,
+		customDocs: make(map[string]map[string]DocResponse),
+		cache:      newLRUCache(docCacheTTL, defaultDocCacheMaxEntries),
+		flight:     newSingleflight(),
+	}
+}
+
+// SetCacheMaxEntries overrides the documentation cache size cap. Non-positive
+// values keep the default.
+func (h *DocumentationHandler) SetCacheMaxEntries(maxEntries int) {
+	h.cache.setMaxEntries(maxEntries)
+}
+
+// SetCacheObserver registers a receiver for documentation cache hit/miss
+// events. Call before serving.
+func (h *DocumentationHandler) SetCacheObserver(observer CacheMetricsObserver) {
+	h.cacheObserver = observer
+}
+
+// CacheSize returns the number of entries currently cached
+func (h *DocumentationHandler) CacheSize() int {
+	return h.cache.len()
+}
+
+// LoadSources loads additional symbol documentation from JSON or YAML files and
+// merges it over the built-ins, with later sources overriding earlier ones.
+// Each file maps a language to a map of symbol names to documentation entries.
+func (h *DocumentationHandler) LoadSources(paths []string) error {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read documentation source %s: %w", path, err)
+		}
+
+		source := map[string]map[string]DocResponse{}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			if err := yaml.Unmarshal(data, &source); err != nil {
+				return fmt.Errorf("failed to parse documentation source %s: %w", path, err)
+			}
+		default:
+			if err := json.Unmarshal(data, &source); err != nil {
+				return fmt.Errorf("failed to parse documentation source %s: %w", path, err)
+			}
+		}
+
+		for language, symbols := range source {
+			language = strings.ToLower(language)
+			if h.customDocs[language] == nil {
+				h.customDocs[language] = make(map[string]DocResponse)
+			}
+			for symbol, doc := range symbols {
+				if doc.Symbol == "" {
+					doc.Symbol = symbol
+				}
+				h.customDocs[language][symbol] = doc
+			}
+		}
+
+		h.logger.WithField("source", path).Info("Loaded documentation source")
+	}
+
+	return nil
+}
+
+// lookupCustomDoc returns custom documentation for a symbol, if loaded
+func (h *DocumentationHandler) lookupCustomDoc(language, symbol string) (*DocResponse, bool) {
+	symbols, ok := h.customDocs[strings.ToLower(language)]
+	if !ok {
+		return nil, false
 	}
+	doc, ok := symbols[symbol]
+	if !ok {
+		return nil, false
+	}
+	return &doc, true
 }
 
 // DocRequest represents a documentation request
 type DocRequest struct {
 	Symbol   string `json:"symbol"`
 	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
 	Context  string `json:"context,omitempty"`
+	// Multiple requests every matching candidate instead of a single best guess
+	Multiple bool `json:"multiple,omitempty"`
+}
+
+// DocCandidatesResponse lists ranked documentation candidates for an
+// ambiguous symbol
+type DocCandidatesResponse struct {
+	Symbol     string        `json:"symbol"`
+	Candidates []DocResponse `json:"candidates"`
 }
 
 // DocResponse represents a documentation response
@@ -43,7 +143,55 @@ func (h *DocumentationHandler) GetDocumentation(request *DocRequest) (*DocRespon
 		"language": request.Language,
 	}).Info("Processing documentation request")
 
-	// Get documentation based on language
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Context)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	// Custom sources take precedence over the built-in maps
+	if doc, ok := h.lookupCustomDoc(request.Language, request.Symbol); ok {
+		return doc, nil
+	}
+
+	// Serve repeated lookups from the bounded cache
+	cacheKey := strings.ToLower(request.Language) + "\x00" + request.Symbol
+	if cached, ok := h.cache.get(cacheKey); ok {
+		h.logger.Debug("Documentation cache hit")
+		if h.cacheObserver != nil {
+			h.cacheObserver.CacheHit("documentation")
+		}
+		return cached.(*DocResponse), nil
+	}
+	if h.cacheObserver != nil {
+		h.cacheObserver.CacheMiss("documentation")
+	}
+
+	// Coalesce concurrent identical lookups (an editor hover burst fires
+	// many at once) into a single underlying fetch shared by every caller
+	value, err := h.flight.do(cacheKey, func() (interface{}, error) {
+		// A caller that queued behind the winning call finds the entry cached
+		if cached, ok := h.cache.get(cacheKey); ok {
+			return cached, nil
+		}
+
+		doc, err := h.lookupDocumentation(request)
+		if err != nil {
+			return nil, err
+		}
+		h.cache.set(cacheKey, doc)
+		return doc, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*DocResponse), nil
+}
+
+// lookupDocumentation resolves a symbol from the per-language sources
+func (h *DocumentationHandler) lookupDocumentation(request *DocRequest) (*DocResponse, error) {
 	switch strings.ToLower(request.Language) {
 	case "go":
 		return h.getGoDocumentation(request.Symbol)
@@ -56,100 +204,188 @@ func (h *DocumentationHandler) GetDocumentation(request *DocRequest) (*DocRespon
 	}
 }
 
-// getGoDocumentation returns Go-specific documentation
-func (h *DocumentationHandler) getGoDocumentation(symbol string) (*DocResponse, error) {
-	// Common Go packages, functions, and methods
-	docs := map[string]DocResponse{
-		"fmt.Println": {
-			Symbol:      "fmt.Println",
-			Description: "Prints to standard output and appends a newline.",
-			Syntax:      "func Println(a ...interface{}) (n int, err error)",
-			Example:     "fmt.Println(\"Hello, World!\")",
-			URL:         "https://pkg.go.dev/fmt#Println",
-		},
-		"http.ListenAndServe": {
-			Symbol:      "http.ListenAndServe",
-			Description: "Starts an HTTP server with a given address and handler.",
-			Syntax:      "func ListenAndServe(addr string, handler Handler) error",
-			Example:     "http.ListenAndServe(\":8080\", nil)",
-			URL:         "https://pkg.go.dev/net/http#ListenAndServe",
-		},
-		"json.Marshal": {
-			Symbol:      "json.Marshal",
-			Description: "Returns the JSON encoding of v.",
-			Syntax:      "func Marshal(v interface{}) ([]byte, error)",
-			Example:     "data, err := json.Marshal(myStruct)",
-			URL:         "https://pkg.go.dev/encoding/json#Marshal",
-		},
-		"struct": {
-			Symbol:      "struct",
-			Description: "A struct is a sequence of named elements, called fields, each of which has a name and a type.",
-			Syntax:      "type StructName struct {\n\tField1 Type1\n\tField2 Type2\n}",
-			Example:     "type Person struct {\n\tName string\n\tAge int\n}",
-			URL:         "https://go.dev/ref/spec#Struct_types",
-		},
-		"interface": {
-			Symbol:      "interface",
-			Description: "An interface type specifies a method set called its interface.",
-			Syntax:      "type InterfaceName interface {\n\tMethod1() ReturnType\n\tMethod2(Type) ReturnType\n}",
-			Example:     "type Writer interface {\n\tWrite(p []byte) (n int, err error)\n}",
-			URL:         "https://go.dev/ref/spec#Interface_types",
-		},
-		"goroutine": {
-			Symbol:      "goroutine",
-			Description: "Goroutines are lightweight threads managed by the Go runtime.",
-			Syntax:      "go functionCall()",
-			Example:     "go func() {\n\tfmt.Println(\"Hello from goroutine\")\n}()",
-			URL:         "https://go.dev/doc/effective_go#goroutines",
-		},
-		"channel": {
-			Symbol:      "channel",
-			Description: "Channels are typed conduits through which you can send and receive values with the channel operator, <-.",
-			Syntax:      "ch := make(chan Type)\nch <- v    // Send v to channel ch\nv := <-ch  // Receive from ch, and assign value to v",
-			Example:     "ch := make(chan int)\ngo func() { ch <- 42 }()\nfmt.Println(<-ch)",
-			URL:         "https://go.dev/doc/effective_go#channels",
-		},
+// GetDocumentationCandidates returns every documentation entry matching the
+// symbol, ranked with exact matches first, then prefix matches, then
+// substring matches. Ambiguous symbols such as method names shared by many
+// types yield all of their candidates so the client can disambiguate.
+func (h *DocumentationHandler) GetDocumentationCandidates(request *DocRequest) ([]DocResponse, error) {
+	h.logger.WithFields(logrus.Fields{
+		"symbol":   request.Symbol,
+		"language": request.Language,
+	}).Info("Processing documentation candidates request")
+
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Context)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	symbol := request.Symbol
+	var pools []map[string]DocResponse
+	switch strings.ToLower(request.Language) {
+	case "go":
+		pools = []map[string]DocResponse{goDocs, goKeywordDocs}
+	case "python":
+		pools = []map[string]DocResponse{pythonDocs}
+	case "sql":
+		symbol = strings.ToUpper(symbol)
+		pools = []map[string]DocResponse{sqlDocs}
+	}
+	if custom, ok := h.customDocs[strings.ToLower(request.Language)]; ok {
+		// Custom sources rank alongside the built-ins
+		pools = append([]map[string]DocResponse{custom}, pools...)
+	}
+
+	candidates := rankedDocCandidates(pools, symbol)
+	if len(candidates) == 0 {
+		// Fall back to the single best-guess response so the candidate list
+		// is never empty
+		doc, err := h.GetDocumentation(request)
+		if err != nil {
+			return nil, err
+		}
+		candidates = []DocResponse{*doc}
+	}
+
+	return candidates, nil
+}
+
+// rankedDocCandidates collects matching entries from the given documentation
+// maps: exact matches first, then prefix matches, then substring matches,
+// alphabetical within each tier
+func rankedDocCandidates(pools []map[string]DocResponse, symbol string) []DocResponse {
+	var exact, prefix, partial []string
+	docs := make(map[string]DocResponse)
+	seen := make(map[string]bool)
+
+	for _, pool := range pools {
+		for key, doc := range pool {
+			if seen[key] {
+				continue
+			}
+			switch {
+			case key == symbol:
+				exact = append(exact, key)
+			case strings.HasPrefix(key, symbol):
+				prefix = append(prefix, key)
+			case strings.Contains(key, symbol):
+				partial = append(partial, key)
+			default:
+				continue
+			}
+			seen[key] = true
+			docs[key] = doc
+		}
 	}
 
+	sort.Strings(prefix)
+	sort.Strings(partial)
+
+	var candidates []DocResponse
+	for _, tier := range [][]string{exact, prefix, partial} {
+		for _, key := range tier {
+			candidates = append(candidates, docs[key])
+		}
+	}
+	return candidates
+}
+
+// goDocs documents common Go packages, functions, and methods
+var goDocs = map[string]DocResponse{
+	"fmt.Println": {
+		Symbol:      "fmt.Println",
+		Description: "Prints to standard output and appends a newline.",
+		Syntax:      "func Println(a ...interface{}) (n int, err error)",
+		Example:     "fmt.Println(\"Hello, World!\")",
+		URL:         "https://pkg.go.dev/fmt#Println",
+	},
+	"http.ListenAndServe": {
+		Symbol:      "http.ListenAndServe",
+		Description: "Starts an HTTP server with a given address and handler.",
+		Syntax:      "func ListenAndServe(addr string, handler Handler) error",
+		Example:     "http.ListenAndServe(\":8080\", nil)",
+		URL:         "https://pkg.go.dev/net/http#ListenAndServe",
+	},
+	"json.Marshal": {
+		Symbol:      "json.Marshal",
+		Description: "Returns the JSON encoding of v.",
+		Syntax:      "func Marshal(v interface{}) ([]byte, error)",
+		Example:     "data, err := json.Marshal(myStruct)",
+		URL:         "https://pkg.go.dev/encoding/json#Marshal",
+	},
+	"struct": {
+		Symbol:      "struct",
+		Description: "A struct is a sequence of named elements, called fields, each of which has a name and a type.",
+		Syntax:      "type StructName struct {\n\tField1 Type1\n\tField2 Type2\n}",
+		Example:     "type Person struct {\n\tName string\n\tAge int\n}",
+		URL:         "https://go.dev/ref/spec#Struct_types",
+	},
+	"interface": {
+		Symbol:      "interface",
+		Description: "An interface type specifies a method set called its interface.",
+		Syntax:      "type InterfaceName interface {\n\tMethod1() ReturnType\n\tMethod2(Type) ReturnType\n}",
+		Example:     "type Writer interface {\n\tWrite(p []byte) (n int, err error)\n}",
+		URL:         "https://go.dev/ref/spec#Interface_types",
+	},
+	"goroutine": {
+		Symbol:      "goroutine",
+		Description: "Goroutines are lightweight threads managed by the Go runtime.",
+		Syntax:      "go functionCall()",
+		Example:     "go func() {\n\tfmt.Println(\"Hello from goroutine\")\n}()",
+		URL:         "https://go.dev/doc/effective_go#goroutines",
+	},
+	"channel": {
+		Symbol:      "channel",
+		Description: "Channels are typed conduits through which you can send and receive values with the channel operator, <-.",
+		Syntax:      "ch := make(chan Type)\nch <- v    // Send v to channel ch\nv := <-ch  // Receive from ch, and assign value to v",
+		Example:     "ch := make(chan int)\ngo func() { ch <- 42 }()\nfmt.Println(<-ch)",
+		URL:         "https://go.dev/doc/effective_go#channels",
+	},
+}
+
+// goKeywordDocs documents Go language keywords
+var goKeywordDocs = map[string]DocResponse{
+	"if": {
+		Symbol:      "if",
+		Description: "Conditional statement that executes code based on the evaluation of a condition.",
+		Syntax:      "if condition {\n\t// code\n} else if condition {\n\t// code\n} else {\n\t// code\n}",
+		Example:     "if x > 0 {\n\tfmt.Println(\"Positive\")\n} else if x < 0 {\n\tfmt.Println(\"Negative\")\n} else {\n\tfmt.Println(\"Zero\")\n}",
+		URL:         "https://go.dev/ref/spec#If_statements",
+	},
+	"for": {
+		Symbol:      "for",
+		Description: "Loop that iterates while a condition is true, or iterates over a range.",
+		Syntax:      "for initialization; condition; post {\n\t// code\n}\n\nfor condition {\n\t// code\n}\n\nfor range expression {\n\t// code\n}",
+		Example:     "for i := 0; i < 10; i++ {\n\tfmt.Println(i)\n}",
+		URL:         "https://go.dev/ref/spec#For_statements",
+	},
+	"switch": {
+		Symbol:      "switch",
+		Description: "Conditional statement that evaluates an expression and executes the matching case.",
+		Syntax:      "switch expression {\ncase value1:\n\t// code\ncase value2:\n\t// code\ndefault:\n\t// code\n}",
+		Example:     "switch day {\ncase \"Monday\":\n\tfmt.Println(\"Start of work week\")\ncase \"Friday\":\n\tfmt.Println(\"End of work week\")\ndefault:\n\tfmt.Println(\"Regular day\")\n}",
+		URL:         "https://go.dev/ref/spec#Switch_statements",
+	},
+}
+
+// getGoDocumentation returns Go-specific documentation
+func (h *DocumentationHandler) getGoDocumentation(symbol string) (*DocResponse, error) {
 	// Check if we have documentation for the symbol
-	if doc, ok := docs[symbol]; ok {
+	if doc, ok := goDocs[symbol]; ok {
 		return &doc, nil
 	}
 
 	// Handle partial matches
-	for key, doc := range docs {
+	for key, doc := range goDocs {
 		if strings.Contains(key, symbol) {
 			return &doc, nil
 		}
 	}
 
-	// Handle Go keywords
-	keywords := map[string]DocResponse{
-		"if": {
-			Symbol:      "if",
-			Description: "Conditional statement that executes code based on the evaluation of a condition.",
-			Syntax:      "if condition {\n\t// code\n} else if condition {\n\t// code\n} else {\n\t// code\n}",
-			Example:     "if x > 0 {\n\tfmt.Println(\"Positive\")\n} else if x < 0 {\n\tfmt.Println(\"Negative\")\n} else {\n\tfmt.Println(\"Zero\")\n}",
-			URL:         "https://go.dev/ref/spec#If_statements",
-		},
-		"for": {
-			Symbol:      "for",
-			Description: "Loop that iterates while a condition is true, or iterates over a range.",
-			Syntax:      "for initialization; condition; post {\n\t// code\n}\n\nfor condition {\n\t// code\n}\n\nfor range expression {\n\t// code\n}",
-			Example:     "for i := 0; i < 10; i++ {\n\tfmt.Println(i)\n}",
-			URL:         "https://go.dev/ref/spec#For_statements",
-		},
-		"switch": {
-			Symbol:      "switch",
-			Description: "Conditional statement that evaluates an expression and executes the matching case.",
-			Syntax:      "switch expression {\ncase value1:\n\t// code\ncase value2:\n\t// code\ndefault:\n\t// code\n}",
-			Example:     "switch day {\ncase \"Monday\":\n\tfmt.Println(\"Start of work week\")\ncase \"Friday\":\n\tfmt.Println(\"End of work week\")\ndefault:\n\tfmt.Println(\"Regular day\")\n}",
-			URL:         "https://go.dev/ref/spec#Switch_statements",
-		},
-	}
-
 	// Check if we have documentation for the keyword
-	if doc, ok := keywords[symbol]; ok {
+	if doc, ok := goKeywordDocs[symbol]; ok {
 		return &doc, nil
 	}
 
@@ -161,61 +397,61 @@ func (h *DocumentationHandler) getGoDocumentation(symbol string) (*DocResponse,
 	}, nil
 }
 
+// pythonDocs documents common Python functions, methods, and modules
+var pythonDocs = map[string]DocResponse{
+	"print": {
+		Symbol:      "print",
+		Description: "Prints the specified message to the screen, or other standard output device.",
+		Syntax:      "print(*objects, sep=' ', end='\\n', file=sys.stdout, flush=False)",
+		Example:     "print(\"Hello, World!\")",
+		URL:         "https://docs.python.org/3/library/functions.html#print",
+	},
+	"len": {
+		Symbol:      "len",
+		Description: "Returns the number of items in an object.",
+		Syntax:      "len(s)",
+		Example:     "length = len([1, 2, 3])",
+		URL:         "https://docs.python.org/3/library/functions.html#len",
+	},
+	"list": {
+		Symbol:      "list",
+		Description: "Creates a list object or converts an iterable to a list.",
+		Syntax:      "list([iterable])",
+		Example:     "my_list = list(range(5))",
+		URL:         "https://docs.python.org/3/library/functions.html#func-list",
+	},
+	"dict": {
+		Symbol:      "dict",
+		Description: "Creates a dictionary object or converts mapping/iterable to a dictionary.",
+		Syntax:      "dict(**kwargs)\ndict(mapping, **kwargs)\ndict(iterable, **kwargs)",
+		Example:     "person = dict(name=\"John\", age=30)",
+		URL:         "https://docs.python.org/3/library/functions.html#func-dict",
+	},
+	"open": {
+		Symbol:      "open",
+		Description: "Opens a file and returns a file object.",
+		Syntax:      "open(file, mode='r', buffering=-1, encoding=None, errors=None, newline=None, closefd=True, opener=None)",
+		Example:     "with open('file.txt', 'r') as f:\n    content = f.read()",
+		URL:         "https://docs.python.org/3/library/functions.html#open",
+	},
+	"range": {
+		Symbol:      "range",
+		Description: "Returns a sequence of numbers, starting from 0 by default, and increments by 1 by default, and stops before a specified number.",
+		Syntax:      "range(stop)\nrange(start, stop[, step])",
+		Example:     "for i in range(5):\n    print(i)",
+		URL:         "https://docs.python.org/3/library/functions.html#func-range",
+	},
+}
+
 // getPythonDocumentation returns Python-specific documentation
 func (h *DocumentationHandler) getPythonDocumentation(symbol string) (*DocResponse, error) {
-	// Common Python functions, methods, and modules
-	docs := map[string]DocResponse{
-		"print": {
-			Symbol:      "print",
-			Description: "Prints the specified message to the screen, or other standard output device.",
-			Syntax:      "print(*objects, sep=' ', end='\\n', file=sys.stdout, flush=False)",
-			Example:     "print(\"Hello, World!\")",
-			URL:         "https://docs.python.org/3/library/functions.html#print",
-		},
-		"len": {
-			Symbol:      "len",
-			Description: "Returns the number of items in an object.",
-			Syntax:      "len(s)",
-			Example:     "length = len([1, 2, 3])",
-			URL:         "https://docs.python.org/3/library/functions.html#len",
-		},
-		"list": {
-			Symbol:      "list",
-			Description: "Creates a list object or converts an iterable to a list.",
-			Syntax:      "list([iterable])",
-			Example:     "my_list = list(range(5))",
-			URL:         "https://docs.python.org/3/library/functions.html#func-list",
-		},
-		"dict": {
-			Symbol:      "dict",
-			Description: "Creates a dictionary object or converts mapping/iterable to a dictionary.",
-			Syntax:      "dict(**kwargs)\ndict(mapping, **kwargs)\ndict(iterable, **kwargs)",
-			Example:     "person = dict(name=\"John\", age=30)",
-			URL:         "https://docs.python.org/3/library/functions.html#func-dict",
-		},
-		"open": {
-			Symbol:      "open",
-			Description: "Opens a file and returns a file object.",
-			Syntax:      "open(file, mode='r', buffering=-1, encoding=None, errors=None, newline=None, closefd=True, opener=None)",
-			Example:     "with open('file.txt', 'r') as f:\n    content = f.read()",
-			URL:         "https://docs.python.org/3/library/functions.html#open",
-		},
-		"range": {
-			Symbol:      "range",
-			Description: "Returns a sequence of numbers, starting from 0 by default, and increments by 1 by default, and stops before a specified number.",
-			Syntax:      "range(stop)\nrange(start, stop[, step])",
-			Example:     "for i in range(5):\n    print(i)",
-			URL:         "https://docs.python.org/3/library/functions.html#func-range",
-		},
-	}
-
 	// Check if we have documentation for the symbol
-	if doc, ok := docs[symbol]; ok {
+	if doc, ok := pythonDocs[symbol]; ok {
 		return &doc, nil
 	}
 
 	// Handle partial matches
-	for key, doc := range docs {
+	for key, doc := range pythonDocs {
 		if strings.Contains(key, symbol) {
 			return &doc, nil
 		}
@@ -229,57 +465,57 @@ func (h *DocumentationHandler) getPythonDocumentation(symbol string) (*DocRespon
 	}, nil
 }
 
+// sqlDocs documents common SQL commands and functions
+var sqlDocs = map[string]DocResponse{
+	"SELECT": {
+		Symbol:      "SELECT",
+		Description: "Extracts data from a database.",
+		Syntax:      "SELECT column1, column2, ...\nFROM table_name\nWHERE condition\nGROUP BY column\nHAVING condition\nORDER BY column;",
+		Example:     "SELECT name, age FROM users WHERE age > 18 ORDER BY name;",
+		URL:         "https://www.w3schools.com/sql/sql_select.asp",
+	},
+	"INSERT": {
+		Symbol:      "INSERT",
+		Description: "Inserts new data into a database.",
+		Syntax:      "INSERT INTO table_name (column1, column2, ...)\nVALUES (value1, value2, ...);",
+		Example:     "INSERT INTO users (name, age) VALUES ('John', 25);",
+		URL:         "https://www.w3schools.com/sql/sql_insert.asp",
+	},
+	"UPDATE": {
+		Symbol:      "UPDATE",
+		Description: "Updates existing data in a database.",
+		Syntax:      "UPDATE table_name\nSET column1 = value1, column2 = value2, ...\nWHERE condition;",
+		Example:     "UPDATE users SET age = 26 WHERE name = 'John';",
+		URL:         "https://www.w3schools.com/sql/sql_update.asp",
+	},
+	"DELETE": {
+		Symbol:      "DELETE",
+		Description: "Deletes data from a database.",
+		Syntax:      "DELETE FROM table_name WHERE condition;",
+		Example:     "DELETE FROM users WHERE name = 'John';",
+		URL:         "https://www.w3schools.com/sql/sql_delete.asp",
+	},
+	"JOIN": {
+		Symbol:      "JOIN",
+		Description: "Combines rows from two or more tables, based on a related column between them.",
+		Syntax:      "SELECT columns\nFROM table1\nJOIN table2\nON table1.column = table2.column;",
+		Example:     "SELECT users.name, orders.product FROM users JOIN orders ON users.id = orders.user_id;",
+		URL:         "https://www.w3schools.com/sql/sql_join.asp",
+	},
+}
+
 // getSQLDocumentation returns SQL-specific documentation
 func (h *DocumentationHandler) getSQLDocumentation(symbol string) (*DocResponse, error) {
-	// Common SQL commands and functions
-	docs := map[string]DocResponse{
-		"SELECT": {
-			Symbol:      "SELECT",
-			Description: "Extracts data from a database.",
-			Syntax:      "SELECT column1, column2, ...\nFROM table_name\nWHERE condition\nGROUP BY column\nHAVING condition\nORDER BY column;",
-			Example:     "SELECT name, age FROM users WHERE age > 18 ORDER BY name;",
-			URL:         "https://www.w3schools.com/sql/sql_select.asp",
-		},
-		"INSERT": {
-			Symbol:      "INSERT",
-			Description: "Inserts new data into a database.",
-			Syntax:      "INSERT INTO table_name (column1, column2, ...)\nVALUES (value1, value2, ...);",
-			Example:     "INSERT INTO users (name, age) VALUES ('John', 25);",
-			URL:         "https://www.w3schools.com/sql/sql_insert.asp",
-		},
-		"UPDATE": {
-			Symbol:      "UPDATE",
-			Description: "Updates existing data in a database.",
-			Syntax:      "UPDATE table_name\nSET column1 = value1, column2 = value2, ...\nWHERE condition;",
-			Example:     "UPDATE users SET age = 26 WHERE name = 'John';",
-			URL:         "https://www.w3schools.com/sql/sql_update.asp",
-		},
-		"DELETE": {
-			Symbol:      "DELETE",
-			Description: "Deletes data from a database.",
-			Syntax:      "DELETE FROM table_name WHERE condition;",
-			Example:     "DELETE FROM users WHERE name = 'John';",
-			URL:         "https://www.w3schools.com/sql/sql_delete.asp",
-		},
-		"JOIN": {
-			Symbol:      "JOIN",
-			Description: "Combines rows from two or more tables, based on a related column between them.",
-			Syntax:      "SELECT columns\nFROM table1\nJOIN table2\nON table1.column = table2.column;",
-			Example:     "SELECT users.name, orders.product FROM users JOIN orders ON users.id = orders.user_id;",
-			URL:         "https://www.w3schools.com/sql/sql_join.asp",
-		},
-	}
-
 	// Convert symbol to uppercase for SQL commands
 	upperSymbol := strings.ToUpper(symbol)
 
 	// Check if we have documentation for the symbol
-	if doc, ok := docs[upperSymbol]; ok {
+	if doc, ok := sqlDocs[upperSymbol]; ok {
 		return &doc, nil
 	}
 
 	// Handle partial matches
-	for key, doc := range docs {
+	for key, doc := range sqlDocs {
 		if strings.Contains(key, upperSymbol) {
 			return &doc, nil
 		}
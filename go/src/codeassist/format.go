@@ -0,0 +1,177 @@
+package codeassist
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FormatHandler handles code formatting requests
+type FormatHandler struct {
+	logger *logrus.Logger
+}
+
+// NewFormatHandler creates a new format handler
+func NewFormatHandler(logger *logrus.Logger) *FormatHandler {
+	return &FormatHandler{
+		logger: logger,
+	}
+}
+
+// FormatRequest represents a formatting request
+type FormatRequest struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+	// Edits requests a minimal line-level diff alongside the formatted code,
+	// so editors can apply incremental edits and preserve cursor position
+	Edits bool `json:"edits,omitempty"`
+}
+
+// TextEdit replaces the half-open line range [StartLine, EndLine) with
+// NewText. Lines are 1-based; an insertion has StartLine == EndLine, and a
+// deletion has an empty NewText.
+type TextEdit struct {
+	StartLine int    `json:"startLine"`
+	EndLine   int    `json:"endLine"`
+	NewText   string `json:"newText"`
+}
+
+// FormatResponse represents a formatting response
+type FormatResponse struct {
+	Formatted string     `json:"formatted"`
+	Changed   bool       `json:"changed"`
+	Edits     []TextEdit `json:"edits,omitempty"`
+}
+
+// FormatCode formats the given code and, when requested, computes the
+// minimal line-level edits turning the original into the formatted text
+func (h *FormatHandler) FormatCode(request *FormatRequest) (*FormatResponse, error) {
+	h.logger.WithFields(logrus.Fields{
+		"language": request.Language,
+		"codeSize": len(request.Code),
+	}).Info("Processing format request")
+
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	var formatted string
+	switch strings.ToLower(request.Language) {
+	case "go":
+		source, err := format.Source([]byte(request.Code))
+		if err != nil {
+			return nil, fmt.Errorf("failed to format Go code: %w", err)
+		}
+		formatted = string(source)
+	default:
+		// For languages without a canonical formatter, normalize line
+		// endings, strip trailing whitespace, and ensure a final newline
+		formatted = normalizeWhitespace(request.Code)
+	}
+
+	response := &FormatResponse{
+		Formatted: formatted,
+		Changed:   formatted != request.Code,
+	}
+	if request.Edits && response.Changed {
+		response.Edits = computeLineEdits(splitLines(request.Code), splitLines(formatted))
+	}
+
+	return response, nil
+}
+
+// normalizeWhitespace converts CRLF to LF, strips trailing whitespace from
+// each line, and ensures the code ends with exactly one newline
+func normalizeWhitespace(code string) string {
+	lines := splitLines(code)
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	// Drop trailing blank lines before re-adding the final newline
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// splitLines splits code into lines, tolerating CRLF endings. A trailing
+// newline does not produce a final empty line.
+func splitLines(code string) []string {
+	code = strings.ReplaceAll(code, "\r\n", "\n")
+	code = strings.TrimSuffix(code, "\n")
+	if code == "" {
+		return nil
+	}
+	return strings.Split(code, "\n")
+}
+
+// computeLineEdits computes the minimal line-level edits turning original
+// into formatted, using a longest-common-subsequence diff. Applying the
+// edits in order (adjusting for earlier length changes) reconstructs the
+// formatted text exactly.
+func computeLineEdits(original, formatted []string) []TextEdit {
+	// lcs[i][j] is the length of the longest common subsequence of
+	// original[i:] and formatted[j:]
+	lcs := make([][]int, len(original)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(formatted)+1)
+	}
+	for i := len(original) - 1; i >= 0; i-- {
+		for j := len(formatted) - 1; j >= 0; j-- {
+			if original[i] == formatted[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []TextEdit
+	i, j := 0, 0
+	for i < len(original) || j < len(formatted) {
+		// Skip runs of matching lines
+		if i < len(original) && j < len(formatted) && original[i] == formatted[j] {
+			i++
+			j++
+			continue
+		}
+
+		// Collect the contiguous block of removed and inserted lines
+		start := i
+		var replacement []string
+		for i < len(original) || j < len(formatted) {
+			if i < len(original) && (j >= len(formatted) || lcs[i+1][j] >= lcs[i][j+1]) {
+				i++
+			} else if j < len(formatted) {
+				replacement = append(replacement, formatted[j])
+				j++
+			}
+			if i < len(original) && j < len(formatted) && original[i] == formatted[j] {
+				break
+			}
+		}
+
+		edit := TextEdit{
+			StartLine: start + 1,
+			EndLine:   i + 1,
+		}
+		if len(replacement) > 0 {
+			edit.NewText = strings.Join(replacement, "\n") + "\n"
+		}
+		edits = append(edits, edit)
+	}
+
+	return edits
+}
@@ -0,0 +1,143 @@
+package codeassist
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// parseGoSource parses code as a Go file, wrapping bare snippets in a
+// synthetic package and function so they still parse. The returned offset is
+// the number of synthetic lines prepended, to subtract from reported
+// positions. A nil file means the code could not be parsed either way.
+func parseGoSource(code string) (*ast.File, *token.FileSet, int) {
+	fset := token.NewFileSet()
+	if file, err := parser.ParseFile(fset, "", code, 0); err == nil {
+		return file, fset, 0
+	}
+
+	wrapped := "package p\n\nfunc _() {\n" + code + "\n}\n"
+	fset = token.NewFileSet()
+	if file, err := parser.ParseFile(fset, "", wrapped, 0); err == nil {
+		return file, fset, 3
+	}
+
+	return nil, nil, 0
+}
+
+// goroutineLeakDiagnostics flags go statements whose function literal bodies
+// block on a channel or make a network call without any cancellation signal:
+// no context use, no Done channel, no multi-case select, no timeout. The
+// check is heuristic — it looks for the usual cancellation idioms rather
+// than proving liveness — and can be disabled via its rule id.
+func goroutineLeakDiagnostics(code string) []AnalysisDiagnostic {
+	file, fset, offset := parseGoSource(code)
+	if file == nil {
+		return nil
+	}
+
+	diagnostics := []AnalysisDiagnostic{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		goStmt, ok := node.(*ast.GoStmt)
+		if !ok {
+			return true
+		}
+		funcLit, ok := goStmt.Call.Fun.(*ast.FuncLit)
+		if !ok {
+			return true
+		}
+
+		blocking := firstBlockingOp(funcLit.Body)
+		if blocking == nil || hasCancellationSignal(funcLit.Body) {
+			return true
+		}
+
+		position := fset.Position(blocking.Pos())
+		diagnostics = append(diagnostics, AnalysisDiagnostic{
+			Message:  "Goroutine blocks on a channel or network call without cancellation and may leak",
+			Severity: "warning",
+			Line:     position.Line - offset,
+			Column:   position.Column,
+			Code:     "go/goroutine-leak",
+		})
+		return true
+	})
+
+	return diagnostics
+}
+
+// firstBlockingOp returns the first unbounded blocking operation in the
+// body: a channel receive or send outside a select, or a call into the net
+// or http packages
+func firstBlockingOp(body *ast.BlockStmt) ast.Node {
+	var blocking ast.Node
+	ast.Inspect(body, func(node ast.Node) bool {
+		if blocking != nil {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.SelectStmt:
+			// A select offers an escape path; whether it actually has one is
+			// judged by hasCancellationSignal
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				blocking = n
+			}
+		case *ast.SendStmt:
+			blocking = n
+		case *ast.CallExpr:
+			if isNetworkCall(n) {
+				blocking = n
+			}
+		}
+		return blocking == nil
+	})
+	return blocking
+}
+
+// hasCancellationSignal reports whether the body shows any of the usual
+// cancellation idioms: a multi-case select, a context, a Done channel, or a
+// timeout helper
+func hasCancellationSignal(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if found {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.SelectStmt:
+			if len(n.Body.List) > 1 {
+				found = true
+			}
+		case *ast.SelectorExpr:
+			switch n.Sel.Name {
+			case "Done", "After", "Deadline", "WithCancel", "WithTimeout", "WithDeadline":
+				found = true
+			}
+		case *ast.Ident:
+			if n.Name == "ctx" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isNetworkCall reports whether the call looks like network I/O: a call into
+// the net or http packages, or a dial/accept method
+func isNetworkCall(call *ast.CallExpr) bool {
+	selector, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	switch selector.Sel.Name {
+	case "Dial", "DialContext", "Accept":
+		return true
+	}
+	if ident, ok := selector.X.(*ast.Ident); ok {
+		return ident.Name == "http" || ident.Name == "net"
+	}
+	return false
+}
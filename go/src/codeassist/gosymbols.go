@@ -0,0 +1,176 @@
+package codeassist
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// goStructMember is a field or method of a file-local struct type
+type goStructMember struct {
+	Name   string
+	Kind   string // "field" or "method"
+	Detail string
+}
+
+// goStructMembers resolves the struct type of a variable declared in the same
+// file and returns its fields and methods. Resolution is file-local only; it
+// returns nil when the variable or its type cannot be found.
+func goStructMembers(code, varName string) []goStructMember {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, 0)
+	if err != nil {
+		return nil
+	}
+
+	structs := collectStructTypes(file)
+	if len(structs) == 0 {
+		return nil
+	}
+
+	typeName := resolveVariableType(file, varName)
+	structType, ok := structs[typeName]
+	if !ok {
+		return nil
+	}
+
+	members := []goStructMember{}
+	for _, field := range structType.Fields.List {
+		detail := exprString(field.Type)
+		for _, name := range field.Names {
+			members = append(members, goStructMember{
+				Name:   name.Name,
+				Kind:   "field",
+				Detail: detail,
+			})
+		}
+	}
+
+	// Methods declared on the type (value or pointer receiver)
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			continue
+		}
+		if receiverTypeName(funcDecl.Recv.List[0].Type) != typeName {
+			continue
+		}
+		members = append(members, goStructMember{
+			Name:   funcDecl.Name.Name + "()",
+			Kind:   "method",
+			Detail: fmt.Sprintf("method of %s", typeName),
+		})
+	}
+
+	return members
+}
+
+// collectStructTypes maps the names of struct types declared in the file to
+// their definitions
+func collectStructTypes(file *ast.File) map[string]*ast.StructType {
+	structs := map[string]*ast.StructType{}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				structs[typeSpec.Name.Name] = structType
+			}
+		}
+	}
+	return structs
+}
+
+// resolveVariableType finds the declared type name of a variable: var
+// declarations, short assignments from composite literals, and function
+// parameters are recognized
+func resolveVariableType(file *ast.File, varName string) string {
+	typeName := ""
+	ast.Inspect(file, func(node ast.Node) bool {
+		if typeName != "" {
+			return false
+		}
+		switch n := node.(type) {
+		case *ast.ValueSpec:
+			// var x T, or var x = T{...}
+			for i, name := range n.Names {
+				if name.Name != varName {
+					continue
+				}
+				if n.Type != nil {
+					typeName = receiverTypeName(n.Type)
+				} else if i < len(n.Values) {
+					typeName = compositeLitTypeName(n.Values[i])
+				}
+			}
+		case *ast.AssignStmt:
+			// x := T{...} or x := &T{...}
+			for i, lhs := range n.Lhs {
+				ident, ok := lhs.(*ast.Ident)
+				if !ok || ident.Name != varName || i >= len(n.Rhs) {
+					continue
+				}
+				typeName = compositeLitTypeName(n.Rhs[i])
+			}
+		case *ast.Field:
+			// function parameters and receivers
+			for _, name := range n.Names {
+				if name.Name == varName {
+					typeName = receiverTypeName(n.Type)
+				}
+			}
+		}
+		return true
+	})
+	return typeName
+}
+
+// compositeLitTypeName extracts the type name from a composite literal
+// expression, unwrapping an address-of operator
+func compositeLitTypeName(expr ast.Expr) string {
+	if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		expr = unary.X
+	}
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		return receiverTypeName(lit.Type)
+	}
+	return ""
+}
+
+// receiverTypeName resolves an identifier or pointer type expression to its
+// base type name
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	default:
+		return ""
+	}
+}
+
+// exprString renders a type expression compactly for completion detail text
+func exprString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return "*" + exprString(t.X)
+	case *ast.ArrayType:
+		return "[]" + exprString(t.Elt)
+	case *ast.MapType:
+		return "map[" + exprString(t.Key) + "]" + exprString(t.Value)
+	case *ast.SelectorExpr:
+		return exprString(t.X) + "." + t.Sel.Name
+	default:
+		return ""
+	}
+}
@@ -2,42 +2,173 @@ package codeassist
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/cploutarchou/mcp-servers/go/features"
+	"github.com/cploutarchou/mcp-servers/go/respond"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 // Handler handles code assistance requests
 type Handler struct {
-	logger              *logrus.Logger
-	completionHandler   *CompletionHandler
-	analysisHandler     *AnalysisHandler
-	documentationHandler *DocumentationHandler
+	logger                *logrus.Logger
+	completionHandler     *CompletionHandler
+	lineCompletionHandler *LineCompletionHandler
+	analysisHandler       *AnalysisHandler
+	documentationHandler  *DocumentationHandler
+	formatHandler         *FormatHandler
+	sqlFormatHandler      *SQLFormatHandler
+	tokenizeHandler       *TokenizeHandler
+	strictParams          bool
 }
 
 // NewHandler creates a new code assistance handler
 func NewHandler(logger *logrus.Logger) *Handler {
 	return &Handler{
-		logger:              logger,
-		completionHandler:   NewCompletionHandler(logger),
-		analysisHandler:     NewAnalysisHandler(logger),
-		documentationHandler: NewDocumentationHandler(logger),
+		logger:                logger,
+		completionHandler:     NewCompletionHandler(logger),
+		lineCompletionHandler: NewLineCompletionHandler(logger),
+		analysisHandler:       NewAnalysisHandler(logger),
+		documentationHandler:  NewDocumentationHandler(logger),
+		formatHandler:         NewFormatHandler(logger),
+		sqlFormatHandler:      NewSQLFormatHandler(logger),
+		tokenizeHandler:       NewTokenizeHandler(logger),
 	}
 }
 
+// SetSeverityOverrides sets per-rule severity overrides for code analysis
+func (h *Handler) SetSeverityOverrides(overrides map[string]string) {
+	h.analysisHandler.SetSeverityOverrides(overrides)
+}
+
+// SetDisabledRules disables the given analysis rule ids
+func (h *Handler) SetDisabledRules(ruleIDs []string) {
+	h.analysisHandler.SetDisabledRules(ruleIDs)
+}
+
+// SetMaxLineLength sets the limit used by the long-line analysis rule
+func (h *Handler) SetMaxLineLength(limit int) {
+	h.analysisHandler.SetMaxLineLength(limit)
+}
+
+// LoadDocumentationSources loads additional documentation files over the built-ins
+func (h *Handler) LoadDocumentationSources(paths []string) error {
+	return h.documentationHandler.LoadSources(paths)
+}
+
+// SetDocCacheMaxEntries caps the number of cached documentation lookups
+func (h *Handler) SetDocCacheMaxEntries(maxEntries int) {
+	h.documentationHandler.SetCacheMaxEntries(maxEntries)
+}
+
+// LoadCompletionKeywords loads extra per-language completion keywords from a file
+func (h *Handler) LoadCompletionKeywords(path string) error {
+	return h.completionHandler.LoadKeywords(path)
+}
+
+// SetStrictParams toggles strict parameter decoding for the MCP code-assist
+// methods: when enabled, requests carrying unknown fields are rejected
+// instead of having them silently dropped
+func (h *Handler) SetStrictParams(enabled bool) {
+	h.strictParams = enabled
+}
+
+// SetMaxCompletionBytes caps the size of code payloads accepted for completion
+func (h *Handler) SetMaxCompletionBytes(limit int) {
+	h.completionHandler.SetMaxCodeBytes(limit)
+}
+
+// SetCompletionTimeout sets the soft deadline for completion requests
+func (h *Handler) SetCompletionTimeout(timeout time.Duration) {
+	h.completionHandler.SetTimeout(timeout)
+}
+
+// SetCompletionPopularity merges popularity overrides over the built-in
+// completion frequency table
+func (h *Handler) SetCompletionPopularity(overrides map[string]int) {
+	h.completionHandler.SetPopularity(overrides)
+}
+
+// CacheMetricsObserver receives hit and miss events from the in-process
+// caches (e.g. into Prometheus counters keyed by cache name)
+type CacheMetricsObserver interface {
+	CacheHit(cache string)
+	CacheMiss(cache string)
+}
+
+// SetCacheMetricsObserver registers a receiver for cache hit/miss events
+func (h *Handler) SetCacheMetricsObserver(observer CacheMetricsObserver) {
+	h.completionHandler.SetCacheObserver(observer)
+	h.documentationHandler.SetCacheObserver(observer)
+}
+
+// SetAnalysisMetricsObserver registers a receiver for per-severity
+// diagnostic counts from analysis runs
+func (h *Handler) SetAnalysisMetricsObserver(observer AnalysisMetricsObserver) {
+	h.analysisHandler.SetMetricsObserver(observer)
+}
+
+// CacheSizes reports the entry counts of the in-process caches
+func (h *Handler) CacheSizes() map[string]int {
+	return map[string]int{
+		"completion":    h.completionHandler.CacheSize(),
+		"documentation": h.documentationHandler.CacheSize(),
+	}
+}
+
+// Warmup exercises each analysis, completion, and documentation path once
+// with small inputs, so the regex engines and caches are primed before the
+// first real request instead of paying the cost on it
+func (h *Handler) Warmup() error {
+	start := time.Now()
+
+	for _, language := range []string{"go", "python", "sql", "text"} {
+		if _, err := h.analysisHandler.AnalyzeCode(&AnalysisRequest{
+			Code:     "SELECT 1;",
+			Language: language,
+		}); err != nil {
+			return fmt.Errorf("warmup analysis for %s failed: %w", language, err)
+		}
+		if _, err := h.completionHandler.GetCompletions(&CompletionRequest{
+			Code:     "x",
+			Language: language,
+		}); err != nil {
+			return fmt.Errorf("warmup completion for %s failed: %w", language, err)
+		}
+	}
+
+	if _, err := h.documentationHandler.GetDocumentation(&DocRequest{
+		Symbol:   "fmt.Println",
+		Language: "go",
+	}); err != nil {
+		return fmt.Errorf("warmup documentation failed: %w", err)
+	}
+
+	h.logger.WithField("duration", time.Since(start).String()).Info("Code assistance warmup complete")
+	return nil
+}
+
 // HandleCompletion handles code completion requests
 func (h *Handler) HandleCompletion(c *gin.Context) {
 	var request CompletionRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		h.logger.WithError(err).Error("Failed to parse completion request")
-		c.JSON(http.StatusBadRequest, gin.H{
+		respond.JSON(c, http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
+	// The lsp-completions feature makes the LSP shape the request default,
+	// for rolling out the new encoding without client body changes
+	if request.Shape == "" && features.Enabled(c, features.LSPCompletions) {
+		request.Shape = ShapeLSP
+	}
+
 	h.logger.WithFields(logrus.Fields{
 		"language": request.Language,
 		"line":     request.Line,
@@ -47,13 +178,28 @@ func (h *Handler) HandleCompletion(c *gin.Context) {
 	response, err := h.completionHandler.GetCompletions(&request)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get completions")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		if errors.Is(err, ErrCodeTooLarge) {
+			respond.JSON(c, http.StatusBadRequest, gin.H{
+				"code":  -32602,
+				"error": err.Error(),
+			})
+			return
+		}
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	if request.Shape == ShapeLSP {
+		respond.JSON(c, http.StatusOK, response.LSP())
+		return
+	}
+	if request.Compact {
+		respond.JSON(c, http.StatusOK, response.Compact())
+		return
+	}
+	respond.JSON(c, http.StatusOK, response)
 }
 
 // HandleAnalysis handles code analysis requests
@@ -61,7 +207,7 @@ func (h *Handler) HandleAnalysis(c *gin.Context) {
 	var request AnalysisRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		h.logger.WithError(err).Error("Failed to parse analysis request")
-		c.JSON(http.StatusBadRequest, gin.H{
+		respond.JSON(c, http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -75,13 +221,56 @@ func (h *Handler) HandleAnalysis(c *gin.Context) {
 	response, err := h.analysisHandler.AnalyzeCode(&request)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to analyze code")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	if request.Compact {
+		respond.JSON(c, http.StatusOK, response.Compact())
+		return
+	}
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// HandleRuleAnalysis runs a single analysis rule against the submitted code,
+// for iterating on one rule without noise from the rest
+func (h *Handler) HandleRuleAnalysis(c *gin.Context) {
+	var request RuleAnalysisRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse rule analysis request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rule, ok := ruleByID(request.RuleID)
+	if !ok {
+		h.logger.WithField("ruleId", request.RuleID).Warn("Unknown analysis rule requested")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"code":  -32602,
+			"error": fmt.Sprintf("unknown rule id: %s", request.RuleID),
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"ruleId":   rule.ID,
+		"language": request.Language,
+	}).Info("Received rule analysis request")
+
+	response, err := h.analysisHandler.AnalyzeRule(rule, &request)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to run analysis rule")
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
 }
 
 // HandleDocumentation handles documentation requests
@@ -89,7 +278,7 @@ func (h *Handler) HandleDocumentation(c *gin.Context) {
 	var request DocRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		h.logger.WithError(err).Error("Failed to parse documentation request")
-		c.JSON(http.StatusBadRequest, gin.H{
+		respond.JSON(c, http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
@@ -100,25 +289,218 @@ func (h *Handler) HandleDocumentation(c *gin.Context) {
 		"language": request.Language,
 	}).Info("Received documentation request")
 
+	// Ranked candidate list for clients disambiguating a symbol
+	if request.Multiple {
+		candidates, err := h.documentationHandler.GetDocumentationCandidates(&request)
+		if err != nil {
+			h.logger.WithError(err).Error("Failed to get documentation")
+			respond.JSON(c, http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		respond.JSON(c, http.StatusOK, DocCandidatesResponse{
+			Symbol:     request.Symbol,
+			Candidates: candidates,
+		})
+		return
+	}
+
 	response, err := h.documentationHandler.GetDocumentation(&request)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get documentation")
-		c.JSON(http.StatusInternalServerError, gin.H{
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// HandleAnalysisRules lists every registered analysis rule with its metadata
+func (h *Handler) HandleAnalysisRules(c *gin.Context) {
+	respond.JSON(c, http.StatusOK, gin.H{
+		"rules": RuleInfos(),
+	})
+}
+
+// HandleFormat handles code formatting requests
+func (h *Handler) HandleFormat(c *gin.Context) {
+	var request FormatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse format request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.formatHandler.FormatCode(&request)
+	if err != nil {
+		// Formatting fails only on unparseable input, which is the client's
+		// code rather than a server fault
+		h.logger.WithError(err).Error("Failed to format code")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// HandleCompletionStream streams completions over SSE for large files: an
+// initial event with the cheap keyword matches, then a refined event with
+// the full provider-backed result for the client to merge. The refined pass
+// respects the completion deadline, so a slow provider ends the stream with
+// whatever was collected rather than holding the connection open.
+func (h *Handler) HandleCompletionStream(c *gin.Context) {
+	var request CompletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse completion stream request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// The lsp-completions feature applies to the streamed events too
+	if request.Shape == "" && features.Enabled(c, features.LSPCompletions) {
+		request.Shape = ShapeLSP
+	}
+
+	// Produce the initial result before committing to the SSE content type,
+	// so payload errors still get a plain JSON status
+	quick, err := h.completionHandler.GetQuickCompletions(&request)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrCodeTooLarge) {
+			status = http.StatusBadRequest
+		}
+		h.logger.WithError(err).Error("Failed to get quick completions")
+		respond.JSON(c, status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+
+	// Both stream events honor the requested response shape
+	if request.Shape == ShapeLSP {
+		c.SSEvent("completions", quick.LSP())
+	} else {
+		c.SSEvent("completions", quick)
+	}
+	c.Writer.Flush()
+
+	response, err := h.completionHandler.GetCompletions(&request)
+	if err != nil {
+		c.SSEvent("error", gin.H{"error": err.Error()})
+		c.Writer.Flush()
+		return
+	}
+
+	if request.Shape == ShapeLSP {
+		c.SSEvent("completions", response.LSP())
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("completions", response)
+	c.Writer.Flush()
+}
+
+// HandleCompleteLine handles whole-line completion requests
+func (h *Handler) HandleCompleteLine(c *gin.Context) {
+	var request LineCompletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse line completion request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.lineCompletionHandler.GetLineCompletions(&request)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get line completions")
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// HandleSQLFormat handles SQL formatting requests
+func (h *Handler) HandleSQLFormat(c *gin.Context) {
+	var request SQLFormatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse SQL format request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.sqlFormatHandler.FormatSQL(&request)
+	if err != nil {
+		// The formatter fails only on missing input, which is the client's
+		// request rather than a server fault
+		h.logger.WithError(err).Error("Failed to format SQL")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// HandleTokenize handles syntax-token extraction requests
+func (h *Handler) HandleTokenize(c *gin.Context) {
+	var request TokenizeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse tokenize request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.tokenizeHandler.Tokenize(&request)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to tokenize code")
+		respond.JSON(c, http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
 }
 
 // RegisterRoutes registers code assistance routes
-func (h *Handler) RegisterRoutes(router *gin.Engine) {
+func (h *Handler) RegisterRoutes(router gin.IRouter) {
 	codeAssistGroup := router.Group("/codeassist")
+	codeAssistGroup.Use(respond.RequireJSON())
 	{
 		codeAssistGroup.POST("/completion", h.HandleCompletion)
+		codeAssistGroup.POST("/completion/stream", h.HandleCompletionStream)
+		codeAssistGroup.POST("/complete-line", h.HandleCompleteLine)
 		codeAssistGroup.POST("/analysis", h.HandleAnalysis)
+		codeAssistGroup.POST("/analysis/batch", h.HandleBatchAnalysis)
+		codeAssistGroup.POST("/analysis/rule", h.HandleRuleAnalysis)
+		codeAssistGroup.GET("/analysis/rules", h.HandleAnalysisRules)
 		codeAssistGroup.POST("/documentation", h.HandleDocumentation)
+		codeAssistGroup.POST("/format", h.HandleFormat)
+		codeAssistGroup.POST("/format/sql", h.HandleSQLFormat)
+		codeAssistGroup.POST("/tokenize", h.HandleTokenize)
+		codeAssistGroup.POST("/suppress", h.HandleSuppress)
 	}
 }
 
@@ -199,6 +581,20 @@ func (h *Handler) RegisterMCPTools() []map[string]interface{} {
 				"required": []string{"symbol", "language"},
 			},
 		},
+		{
+			"name":        "sql_format",
+			"description": "Validate and pretty-print a SQL query without executing it",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The SQL query to format, possibly multi-statement",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
 	}
 }
 
@@ -209,22 +605,55 @@ func (h *Handler) HandleMCPRequest(method string, params json.RawMessage) (inter
 	switch method {
 	case "code_completion":
 		var request CompletionRequest
-		if err := json.Unmarshal(params, &request); err != nil {
+		if err := UnmarshalParams(params, &request, h.strictParams); err != nil {
+			return nil, err
+		}
+		response, err := h.completionHandler.GetCompletions(&request)
+		if err != nil {
 			return nil, err
 		}
-		return h.completionHandler.GetCompletions(&request)
+		if request.Shape == ShapeLSP {
+			return response.LSP(), nil
+		}
+		if request.Compact {
+			return response.Compact(), nil
+		}
+		return response, nil
 	case "code_analysis":
 		var request AnalysisRequest
-		if err := json.Unmarshal(params, &request); err != nil {
+		if err := UnmarshalParams(params, &request, h.strictParams); err != nil {
 			return nil, err
 		}
-		return h.analysisHandler.AnalyzeCode(&request)
+		response, err := h.analysisHandler.AnalyzeCode(&request)
+		if err != nil {
+			return nil, err
+		}
+		if request.Compact {
+			return response.Compact(), nil
+		}
+		return response, nil
 	case "code_documentation":
 		var request DocRequest
-		if err := json.Unmarshal(params, &request); err != nil {
+		if err := UnmarshalParams(params, &request, h.strictParams); err != nil {
 			return nil, err
 		}
+		if request.Multiple {
+			candidates, err := h.documentationHandler.GetDocumentationCandidates(&request)
+			if err != nil {
+				return nil, err
+			}
+			return DocCandidatesResponse{
+				Symbol:     request.Symbol,
+				Candidates: candidates,
+			}, nil
+		}
 		return h.documentationHandler.GetDocumentation(&request)
+	case "sql_format":
+		var request SQLFormatRequest
+		if err := UnmarshalParams(params, &request, h.strictParams); err != nil {
+			return nil, err
+		}
+		return h.sqlFormatHandler.FormatSQL(&request)
 	default:
 		return nil, fmt.Errorf("unknown method: %s", method)
 	}
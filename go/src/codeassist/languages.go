@@ -0,0 +1,103 @@
+package codeassist
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// defaultLanguageAliases maps the language ids clients commonly send to the
+// canonical ids used by the handlers
+var defaultLanguageAliases = map[string]string{
+	"golang":     "go",
+	"py":         "python",
+	"py3":        "python",
+	"python3":    "python",
+	"postgres":   "sql",
+	"postgresql": "sql",
+	"mysql":      "sql",
+	"sqlite":     "sql",
+}
+
+// languageAliases is the active alias table; configured aliases are merged
+// over the defaults at startup
+var languageAliases = defaultLanguageAliases
+
+// SetLanguageAliases merges configured language aliases over the built-in
+// defaults. It must be called before the server starts handling requests.
+func SetLanguageAliases(aliases map[string]string) {
+	merged := make(map[string]string, len(defaultLanguageAliases)+len(aliases))
+	for alias, language := range defaultLanguageAliases {
+		merged[alias] = language
+	}
+	for alias, language := range aliases {
+		merged[strings.ToLower(alias)] = strings.ToLower(language)
+	}
+	languageAliases = merged
+}
+
+// NormalizeLanguage resolves a client-supplied language id to its canonical form
+func NormalizeLanguage(language string) string {
+	language = strings.ToLower(language)
+	if canonical, ok := languageAliases[language]; ok {
+		return canonical
+	}
+	return language
+}
+
+// defaultExtensionLanguages maps file extensions to language ids
+var defaultExtensionLanguages = map[string]string{
+	".go":  "go",
+	".py":  "python",
+	".sql": "sql",
+	".ts":  "typescript",
+	".js":  "javascript",
+}
+
+// extensionLanguages is the active extension table; configured mappings are
+// merged over the defaults at startup
+var extensionLanguages = defaultExtensionLanguages
+
+// SetExtensionLanguages merges configured extension-to-language mappings over
+// the built-in defaults. It must be called before the server starts handling
+// requests.
+func SetExtensionLanguages(extensions map[string]string) {
+	merged := make(map[string]string, len(defaultExtensionLanguages)+len(extensions))
+	for extension, language := range defaultExtensionLanguages {
+		merged[extension] = language
+	}
+	for extension, language := range extensions {
+		extension = strings.ToLower(extension)
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		merged[extension] = strings.ToLower(language)
+	}
+	extensionLanguages = merged
+}
+
+// detectLanguage infers the language from the file extension of path, falling
+// back to cheap content heuristics when the path has no known extension
+func detectLanguage(path, code string) string {
+	if extension := strings.ToLower(filepath.Ext(path)); extension != "" {
+		if language, ok := extensionLanguages[extension]; ok {
+			return language
+		}
+	}
+
+	// Content heuristics for extensionless paths
+	trimmed := strings.TrimSpace(code)
+	switch {
+	case strings.HasPrefix(trimmed, "package ") || strings.Contains(code, "func "):
+		return "go"
+	case strings.Contains(code, "def ") || strings.HasPrefix(trimmed, "#!") && strings.Contains(trimmed, "python"):
+		return "python"
+	default:
+		upper := strings.ToUpper(trimmed)
+		if strings.HasPrefix(upper, "SELECT") || strings.HasPrefix(upper, "INSERT") ||
+			strings.HasPrefix(upper, "UPDATE") || strings.HasPrefix(upper, "DELETE") {
+			return "sql"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,113 @@
+package codeassist
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry is a cached value with its key and expiry
+type lruEntry struct {
+	key     string
+	value   interface{}
+	expires time.Time
+}
+
+// lruCache is a TTL cache bounded by a least-recently-used size cap, so
+// memory stays bounded even when many distinct keys are queried
+type lruCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	ttl        time.Duration
+	maxEntries int
+}
+
+// newLRUCache creates a cache with the given TTL and size cap
+func newLRUCache(ttl time.Duration, maxEntries int) *lruCache {
+	return &lruCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// setMaxEntries adjusts the size cap, evicting entries if now over it.
+// Non-positive values keep the current cap.
+func (c *lruCache) setMaxEntries(maxEntries int) {
+	if maxEntries <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.maxEntries = maxEntries
+	c.evictOver()
+}
+
+// len returns the number of entries currently cached
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// get returns the cached value for a key if still valid, marking it as
+// recently used
+func (c *lruCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// set stores a value for the TTL, evicting least-recently-used entries when
+// the cap is exceeded
+func (c *lruCache) set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value = value
+		entry.expires = time.Now().Add(c.ttl)
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(c.ttl),
+	})
+	c.evictOver()
+}
+
+// evictOver removes least-recently-used entries until the cap is met.
+// Callers must hold the lock.
+func (c *lruCache) evictOver() {
+	for len(c.entries) > c.maxEntries {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
@@ -0,0 +1,48 @@
+package codeassist
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownParam is returned by strict parameter decoding when a request
+// carries a field no handler recognises — most often a typo'd parameter name
+// (e.g. "langauge") that lenient decoding would silently drop
+var ErrUnknownParam = errors.New("unknown parameter")
+
+// UnmarshalParams decodes JSON-RPC params into dst. In strict mode an unknown
+// field is an error naming the field instead of being silently ignored; the
+// protocol-level _meta member is always allowed, so correlation metadata
+// never trips the check
+func UnmarshalParams(params json.RawMessage, dst interface{}, strict bool) error {
+	if !strict {
+		return json.Unmarshal(params, dst)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(params, &raw); err != nil {
+		// Not a JSON object; let the plain decode report the type mismatch
+		return json.Unmarshal(params, dst)
+	}
+	delete(raw, "_meta")
+	cleaned, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(cleaned))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		// The standard library exposes no typed error for this case, so
+		// recover the field name from the message to produce a matchable
+		// error that still names the offending field
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			return fmt.Errorf("%w: %s", ErrUnknownParam, strings.TrimPrefix(err.Error(), "json: unknown field "))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package codeassist
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+)
+
+// openResource is one assignment from a resource-returning call: the variable
+// holding the resource and whether the closer is the variable itself
+// (os.Open, db.Query) or its Body field (http.Get)
+type openResource struct {
+	name       string
+	bodyCloser bool
+	pos        token.Pos
+}
+
+// missingCloseDiagnostics flags assignments from known resource-returning
+// calls (os.Open, http.Get, db.Query and friends) whose returned closer is
+// never Close'd within the function. The check is heuristic — a resource
+// returned to the caller or closed through another name is skipped or missed
+// rather than proven — and can be disabled via its rule id.
+func missingCloseDiagnostics(code string) []AnalysisDiagnostic {
+	file, fset, offset := parseGoSource(code)
+	if file == nil {
+		return nil
+	}
+
+	diagnostics := []AnalysisDiagnostic{}
+	ast.Inspect(file, func(node ast.Node) bool {
+		var body *ast.BlockStmt
+		switch n := node.(type) {
+		case *ast.FuncDecl:
+			body = n.Body
+		case *ast.FuncLit:
+			body = n.Body
+		default:
+			return true
+		}
+		if body == nil {
+			return true
+		}
+
+		for _, resource := range openResourcesInFunc(body) {
+			if closesResource(body, resource) || returnsIdent(body, resource.name) {
+				continue
+			}
+			closer := resource.name + ".Close()"
+			if resource.bodyCloser {
+				closer = resource.name + ".Body.Close()"
+			}
+			position := fset.Position(resource.pos)
+			diagnostics = append(diagnostics, AnalysisDiagnostic{
+				Message:  fmt.Sprintf("Resource %q is never closed; add defer %s after the error check", resource.name, closer),
+				Severity: "warning",
+				Line:     position.Line - offset,
+				Column:   position.Column,
+				Code:     "go/missing-close",
+			})
+		}
+		return true
+	})
+
+	return diagnostics
+}
+
+// openResourcesInFunc collects assignments from resource-returning calls in
+// the function's own body. Nested function literals are skipped; they are
+// visited as functions in their own right.
+func openResourcesInFunc(body *ast.BlockStmt) []openResource {
+	var resources []openResource
+	ast.Inspect(body, func(node ast.Node) bool {
+		if _, ok := node.(*ast.FuncLit); ok {
+			return false
+		}
+		assign, ok := node.(*ast.AssignStmt)
+		if !ok || len(assign.Rhs) != 1 {
+			return true
+		}
+		call, ok := assign.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		bodyCloser, ok := resourceCloser(call)
+		if !ok {
+			return true
+		}
+		ident, ok := assign.Lhs[0].(*ast.Ident)
+		if !ok || ident.Name == "_" {
+			return true
+		}
+		resources = append(resources, openResource{
+			name:       ident.Name,
+			bodyCloser: bodyCloser,
+			pos:        assign.Pos(),
+		})
+		return true
+	})
+	return resources
+}
+
+// resourceCloser reports whether the call returns something that must be
+// closed, and whether the closer is the result's Body field rather than the
+// result itself
+func resourceCloser(call *ast.CallExpr) (bodyCloser bool, ok bool) {
+	selector, isSelector := call.Fun.(*ast.SelectorExpr)
+	if !isSelector {
+		return false, false
+	}
+
+	if receiver, isIdent := selector.X.(*ast.Ident); isIdent {
+		switch receiver.Name {
+		case "os":
+			switch selector.Sel.Name {
+			case "Open", "OpenFile", "Create":
+				return false, true
+			}
+		case "http":
+			switch selector.Sel.Name {
+			case "Get", "Post", "PostForm", "Head":
+				return true, true
+			}
+		}
+	}
+
+	// Method calls returning row sets, on whatever the receiver is named
+	switch selector.Sel.Name {
+	case "Query", "QueryContext":
+		return false, true
+	}
+
+	return false, false
+}
+
+// closesResource reports whether the body contains a Close call on the
+// resource, deferred or not: name.Close() for direct closers, or
+// name.Body.Close() for response bodies
+func closesResource(body *ast.BlockStmt, resource openResource) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if found {
+			return false
+		}
+		call, ok := node.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || selector.Sel.Name != "Close" {
+			return true
+		}
+		if resource.bodyCloser {
+			inner, ok := selector.X.(*ast.SelectorExpr)
+			if !ok || inner.Sel.Name != "Body" {
+				return true
+			}
+			if ident, ok := inner.X.(*ast.Ident); ok && ident.Name == resource.name {
+				found = true
+			}
+			return true
+		}
+		if ident, ok := selector.X.(*ast.Ident); ok && ident.Name == resource.name {
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// returnsIdent reports whether the body returns the named variable, in which
+// case closing it is the caller's responsibility
+func returnsIdent(body *ast.BlockStmt, name string) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		if found {
+			return false
+		}
+		returnStmt, ok := node.(*ast.ReturnStmt)
+		if !ok {
+			return true
+		}
+		for _, result := range returnStmt.Results {
+			if ident, ok := result.(*ast.Ident); ok && ident.Name == name {
+				found = true
+			}
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,71 @@
+package codeassist
+
+// AnalysisRule describes a single built-in analysis rule. The registry backs
+// rule-authoring endpoints; adding a rule to an analyzer should come with a
+// matching entry here.
+type AnalysisRule struct {
+	ID          string `json:"id"`
+	Language    string `json:"language"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+}
+
+// analysisRules lists every rule the analyzers can emit, keyed by the Code
+// field set on their diagnostics
+var analysisRules = []AnalysisRule{
+	{ID: "go/unused-import", Language: "go", Severity: "warning", Description: "Imported package is never referenced"},
+	{ID: "go/naked-return", Language: "go", Severity: "info", Description: "Naked return should be avoided for readability"},
+	{ID: "go/unwrapped-error", Language: "go", Severity: "info", Description: "Bare error return loses context; consider wrapping with %w"},
+	{ID: "go/goroutine-leak", Language: "go", Severity: "warning", Description: "Goroutine blocks without cancellation and may leak"},
+	{ID: "go/missing-close", Language: "go", Severity: "warning", Description: "Opened resource is never closed; add a defer Close"},
+	{ID: "python/print-statement", Language: "python", Severity: "error", Description: "Python 3 requires parentheses for the print function"},
+	{ID: "python/bare-except", Language: "python", Severity: "warning", Description: "Bare except catches all exceptions including KeyboardInterrupt"},
+	{ID: "python/mutable-default-arg", Language: "python", Severity: "warning", Description: "Mutable default argument can cause unexpected behavior"},
+	{ID: "python/fstring-no-placeholder", Language: "python", Severity: "info", Description: "f-string has no placeholders; the f prefix is unnecessary"},
+	{ID: "python/unused-import", Language: "python", Severity: "warning", Description: "Imported module is never referenced"},
+	{ID: "sql/select-star", Language: "sql", Severity: "warning", Description: "SELECT * can impact performance and return unnecessary columns"},
+	{ID: "sql/missing-where", Language: "sql", Severity: "error", Description: "UPDATE or DELETE without WHERE affects all rows"},
+	{ID: "sql/missing-limit", Language: "sql", Severity: "info", Description: "SELECT without WHERE or LIMIT may scan the entire table"},
+	{ID: "sql/correlated-subquery", Language: "sql", Severity: "info", Description: "Subquery in the SELECT list may execute once per row"},
+	{ID: "sql/string-concat", Language: "sql", Severity: "error", Description: "String concatenation in SQL can lead to injection vulnerabilities"},
+	{ID: "generic/long-line", Language: "any", Severity: "info", Description: "Line exceeds the maximum length"},
+	{ID: "generic/todo-comment", Language: "any", Severity: "info", Description: "TODO comment found"},
+	{ID: "generic/hardcoded-credential", Language: "any", Severity: "warning", Description: "Potential hardcoded credential detected"},
+}
+
+// ruleByID looks up a rule in the registry by its id
+func ruleByID(id string) (AnalysisRule, bool) {
+	for _, rule := range analysisRules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return AnalysisRule{}, false
+}
+
+// RuleInfo describes a registered rule for clients building settings UIs
+type RuleInfo struct {
+	ID              string   `json:"id"`
+	Description     string   `json:"description"`
+	DefaultSeverity string   `json:"defaultSeverity"`
+	Languages       []string `json:"languages"`
+}
+
+// RuleInfos describes every registered rule. The list is derived from the
+// registry, so it stays in sync with the rules the analyzers emit.
+func RuleInfos() []RuleInfo {
+	infos := make([]RuleInfo, 0, len(analysisRules))
+	for _, rule := range analysisRules {
+		languages := []string{rule.Language}
+		if rule.Language == "any" {
+			languages = []string{"go", "python", "sql", "generic"}
+		}
+		infos = append(infos, RuleInfo{
+			ID:              rule.ID,
+			Description:     rule.Description,
+			DefaultSeverity: rule.Severity,
+			Languages:       languages,
+		})
+	}
+	return infos
+}
@@ -0,0 +1,50 @@
+package codeassist
+
+import "sync"
+
+// singleflight coalesces concurrent calls sharing a key, so duplicate work
+// (e.g. identical documentation lookups fired by an editor hover burst) runs
+// once and every caller receives the same result
+type singleflight struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+// singleflightCall is one in-flight invocation and its eventual result
+type singleflightCall struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+}
+
+// newSingleflight creates an empty call group
+func newSingleflight() *singleflight {
+	return &singleflight{
+		calls: make(map[string]*singleflightCall),
+	}
+}
+
+// do runs fn at most once per key at a time; callers arriving while a call
+// for the same key is in flight wait for it and share its result
+func (g *singleflight) do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		call.wg.Wait()
+		return call.value, call.err
+	}
+
+	call := &singleflightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn()
+	call.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return call.value, call.err
+}
@@ -0,0 +1,197 @@
+package codeassist
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SQLFormatHandler normalizes SQL queries and decomposes them into clauses
+// without touching a database, so query structure can be checked offline
+type SQLFormatHandler struct {
+	logger *logrus.Logger
+}
+
+// NewSQLFormatHandler creates a new SQL format handler
+func NewSQLFormatHandler(logger *logrus.Logger) *SQLFormatHandler {
+	return &SQLFormatHandler{
+		logger: logger,
+	}
+}
+
+// SQLFormatRequest represents a SQL formatting request
+type SQLFormatRequest struct {
+	Query string `json:"query"`
+}
+
+// SQLClause is one top-level clause of a statement: the leading keyword
+// (e.g. SELECT, LEFT JOIN, GROUP BY) and the text that follows it
+type SQLClause struct {
+	Keyword string `json:"keyword"`
+	Text    string `json:"text"`
+}
+
+// SQLStatement is one formatted statement with its structural breakdown
+type SQLStatement struct {
+	Formatted string      `json:"formatted"`
+	Clauses   []SQLClause `json:"clauses"`
+}
+
+// SQLFormatResponse represents a SQL formatting response. Multi-statement
+// input yields one entry per statement, in input order.
+type SQLFormatResponse struct {
+	Statements []SQLStatement `json:"statements"`
+}
+
+// sqlClauseStarters are the keyword sequences that begin a top-level clause,
+// ordered so longer sequences are matched before their prefixes
+var sqlClauseStarters = [][]string{
+	{"SELECT"},
+	{"INSERT", "INTO"},
+	{"UPDATE"},
+	{"DELETE", "FROM"},
+	{"FROM"},
+	{"LEFT", "OUTER", "JOIN"},
+	{"RIGHT", "OUTER", "JOIN"},
+	{"FULL", "OUTER", "JOIN"},
+	{"LEFT", "JOIN"},
+	{"RIGHT", "JOIN"},
+	{"FULL", "JOIN"},
+	{"INNER", "JOIN"},
+	{"CROSS", "JOIN"},
+	{"JOIN"},
+	{"WHERE"},
+	{"GROUP", "BY"},
+	{"HAVING"},
+	{"ORDER", "BY"},
+	{"LIMIT"},
+	{"OFFSET"},
+	{"UNION", "ALL"},
+	{"UNION"},
+	{"VALUES"},
+	{"SET"},
+	{"RETURNING"},
+}
+
+// FormatSQL normalizes the query's whitespace, splits it into statements, and
+// breaks each statement into clauses, one clause per line with the keyword
+// uppercased
+func (h *SQLFormatHandler) FormatSQL(request *SQLFormatRequest) (*SQLFormatResponse, error) {
+	if strings.TrimSpace(request.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+
+	h.logger.WithField("querySize", len(request.Query)).Info("Processing SQL format request")
+
+	response := &SQLFormatResponse{
+		Statements: []SQLStatement{},
+	}
+	for _, statement := range splitSQLStatements(request.Query) {
+		clauses := splitSQLClauses(statement.text)
+		lines := make([]string, 0, len(clauses))
+		for _, clause := range clauses {
+			if clause.Text == "" {
+				lines = append(lines, clause.Keyword)
+				continue
+			}
+			lines = append(lines, clause.Keyword+" "+clause.Text)
+		}
+		response.Statements = append(response.Statements, SQLStatement{
+			Formatted: strings.Join(lines, "\n"),
+			Clauses:   clauses,
+		})
+	}
+	return response, nil
+}
+
+// splitSQLClauses tokenizes a statement quote-aware and cuts it at clause
+// keywords appearing at parenthesis depth zero, so subqueries stay inside
+// their enclosing clause
+func splitSQLClauses(statement string) []SQLClause {
+	words := splitSQLWords(statement)
+	if len(words) == 0 {
+		return []SQLClause{}
+	}
+
+	var clauses []SQLClause
+	keyword := ""
+	var body []string
+	depth := 0
+	for i := 0; i < len(words); {
+		depth += strings.Count(words[i], "(") - strings.Count(words[i], ")")
+		starter := 0
+		if depth == 0 {
+			starter = matchClauseStarter(words[i:])
+		}
+		if starter > 0 {
+			if keyword != "" || len(body) > 0 {
+				clauses = append(clauses, SQLClause{Keyword: keyword, Text: strings.Join(body, " ")})
+			}
+			keyword = strings.ToUpper(strings.Join(words[i:i+starter], " "))
+			body = nil
+			i += starter
+			continue
+		}
+		body = append(body, words[i])
+		i++
+	}
+	if keyword != "" || len(body) > 0 {
+		clauses = append(clauses, SQLClause{Keyword: keyword, Text: strings.Join(body, " ")})
+	}
+	return clauses
+}
+
+// matchClauseStarter returns the number of words consumed by the longest
+// clause starter beginning at words[0], or zero if none matches
+func matchClauseStarter(words []string) int {
+	for _, starter := range sqlClauseStarters {
+		if len(starter) > len(words) {
+			continue
+		}
+		matched := true
+		for j, keyword := range starter {
+			if !strings.EqualFold(words[j], keyword) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return len(starter)
+		}
+	}
+	return 0
+}
+
+// splitSQLWords splits a statement into whitespace-separated words while
+// keeping quoted literals intact, so a space inside a string never starts a
+// new word
+func splitSQLWords(statement string) []string {
+	var words []string
+	var current strings.Builder
+	var quote byte
+	for i := 0; i < len(statement); i++ {
+		ch := statement[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(ch)
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			current.WriteByte(ch)
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteByte(ch)
+		}
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words
+}
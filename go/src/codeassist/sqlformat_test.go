@@ -0,0 +1,79 @@
+package codeassist
+
+import "testing"
+
+func TestFormatSQLBreaksClausesOntoLines(t *testing.T) {
+	h := NewSQLFormatHandler(testLogger())
+
+	response, err := h.FormatSQL(&SQLFormatRequest{
+		Query: "select id, name from users where active = true order by name",
+	})
+	if err != nil {
+		t.Fatalf("FormatSQL failed: %v", err)
+	}
+	if len(response.Statements) != 1 {
+		t.Fatalf("expected 1 statement, got %d", len(response.Statements))
+	}
+
+	want := "SELECT id, name\nFROM users\nWHERE active = true\nORDER BY name"
+	if got := response.Statements[0].Formatted; got != want {
+		t.Errorf("formatted mismatch:\ngot  %q\nwant %q", got, want)
+	}
+
+	keywords := []string{"SELECT", "FROM", "WHERE", "ORDER BY"}
+	clauses := response.Statements[0].Clauses
+	if len(clauses) != len(keywords) {
+		t.Fatalf("expected %d clauses, got %+v", len(keywords), clauses)
+	}
+	for i, keyword := range keywords {
+		if clauses[i].Keyword != keyword {
+			t.Errorf("clause %d keyword %q, want %q", i, clauses[i].Keyword, keyword)
+		}
+	}
+}
+
+func TestFormatSQLKeepsSubqueriesInTheirClause(t *testing.T) {
+	h := NewSQLFormatHandler(testLogger())
+
+	response, err := h.FormatSQL(&SQLFormatRequest{
+		Query: "select id from users where id in (select user_id from orders)",
+	})
+	if err != nil {
+		t.Fatalf("FormatSQL failed: %v", err)
+	}
+
+	clauses := response.Statements[0].Clauses
+	if len(clauses) != 3 {
+		t.Fatalf("subquery split out of its clause: %+v", clauses)
+	}
+	if clauses[2].Keyword != "WHERE" || clauses[2].Text != "id in (select user_id from orders)" {
+		t.Errorf("unexpected WHERE clause: %+v", clauses[2])
+	}
+}
+
+func TestFormatSQLSplitsStatements(t *testing.T) {
+	h := NewSQLFormatHandler(testLogger())
+
+	response, err := h.FormatSQL(&SQLFormatRequest{
+		Query: "select 1; select 'a;b' from t",
+	})
+	if err != nil {
+		t.Fatalf("FormatSQL failed: %v", err)
+	}
+
+	// The semicolon inside the string literal must not split the statement
+	if len(response.Statements) != 2 {
+		t.Fatalf("expected 2 statements, got %+v", response.Statements)
+	}
+	if got := response.Statements[1].Formatted; got != "SELECT 'a;b'\nFROM t" {
+		t.Errorf("quoted semicolon mishandled: %q", got)
+	}
+}
+
+func TestFormatSQLRejectsEmptyQuery(t *testing.T) {
+	h := NewSQLFormatHandler(testLogger())
+
+	if _, err := h.FormatSQL(&SQLFormatRequest{Query: "   "}); err == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
@@ -0,0 +1,119 @@
+package codeassist
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/cploutarchou/mcp-servers/go/respond"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SuppressRequest represents a request for a diagnostic-suppression edit
+type SuppressRequest struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	Line     int    `json:"line"`
+	RuleID   string `json:"ruleId"`
+}
+
+// SuppressEdit is a text edit to insert at the given position. Column and
+// EndColumn are equal for pure insertions.
+type SuppressEdit struct {
+	Line      int    `json:"line"`
+	Column    int    `json:"column"`
+	EndColumn int    `json:"endColumn"`
+	NewText   string `json:"newText"`
+}
+
+// SuppressResponse represents a suppression edit response
+type SuppressResponse struct {
+	Edit *SuppressEdit `json:"edit,omitempty"`
+}
+
+// suppressionMarker returns the inline suppression comment marker for a language
+func suppressionMarker(language string) string {
+	switch NormalizeLanguage(language) {
+	case "go":
+		return "//nolint:"
+	case "python":
+		return "# noqa: "
+	case "sql":
+		return "-- nolint:"
+	default:
+		return "// nolint:"
+	}
+}
+
+// SuppressionEdit computes the text edit that silences the given rule on the
+// given line, appending to an existing suppression comment when present
+func SuppressionEdit(request *SuppressRequest) (*SuppressResponse, error) {
+	lines := strings.Split(request.Code, "\n")
+	if request.Line < 1 || request.Line > len(lines) {
+		return nil, fmt.Errorf("line %d is out of range", request.Line)
+	}
+	if request.RuleID == "" {
+		return nil, fmt.Errorf("ruleId is required")
+	}
+
+	target := lines[request.Line-1]
+	marker := suppressionMarker(request.Language)
+	end := len(target)
+
+	if idx := strings.Index(target, marker); idx != -1 {
+		// A suppression comment already exists; append the rule id to it
+		existing := target[idx+len(marker):]
+		for _, rule := range strings.Split(existing, ",") {
+			if strings.TrimSpace(rule) == request.RuleID {
+				// Already suppressed; nothing to do
+				return &SuppressResponse{}, nil
+			}
+		}
+		return &SuppressResponse{
+			Edit: &SuppressEdit{
+				Line:      request.Line,
+				Column:    end,
+				EndColumn: end,
+				NewText:   "," + request.RuleID,
+			},
+		}, nil
+	}
+
+	return &SuppressResponse{
+		Edit: &SuppressEdit{
+			Line:      request.Line,
+			Column:    end,
+			EndColumn: end,
+			NewText:   " " + marker + request.RuleID,
+		},
+	}, nil
+}
+
+// HandleSuppress handles diagnostic-suppression requests over HTTP
+func (h *Handler) HandleSuppress(c *gin.Context) {
+	var request SuppressRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.logger.WithError(err).Error("Failed to parse suppress request")
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"language": request.Language,
+		"line":     request.Line,
+		"ruleId":   request.RuleID,
+	}).Info("Received suppress request")
+
+	response, err := SuppressionEdit(&request)
+	if err != nil {
+		respond.JSON(c, http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
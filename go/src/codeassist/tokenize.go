@@ -0,0 +1,255 @@
+package codeassist
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TokenizeHandler handles syntax-token extraction requests
+type TokenizeHandler struct {
+	logger *logrus.Logger
+}
+
+// NewTokenizeHandler creates a new tokenize handler
+func NewTokenizeHandler(logger *logrus.Logger) *TokenizeHandler {
+	return &TokenizeHandler{
+		logger: logger,
+	}
+}
+
+// TokenizeRequest represents a syntax-token extraction request
+type TokenizeRequest struct {
+	Code     string `json:"code"`
+	Language string `json:"language"`
+	Path     string `json:"path,omitempty"`
+}
+
+// SyntaxToken classifies a span of source text for semantic highlighting.
+// Line and StartCol are 1-based; TokenType is one of the standard LSP
+// semantic token types (keyword, variable, string, number, comment, operator).
+type SyntaxToken struct {
+	Line      int    `json:"line"`
+	StartCol  int    `json:"startCol"`
+	Length    int    `json:"length"`
+	TokenType string `json:"tokenType"`
+}
+
+// TokenizeResponse represents a syntax-token extraction response
+type TokenizeResponse struct {
+	Tokens []SyntaxToken `json:"tokens"`
+}
+
+// Tokenize classifies the tokens of the given code for semantic highlighting
+func (h *TokenizeHandler) Tokenize(request *TokenizeRequest) (*TokenizeResponse, error) {
+	h.logger.WithFields(logrus.Fields{
+		"language": request.Language,
+		"codeSize": len(request.Code),
+	}).Info("Processing tokenize request")
+
+	// Infer the language from the file path when the client omits it
+	if request.Language == "" {
+		request.Language = detectLanguage(request.Path, request.Code)
+	}
+
+	// Normalize client language ids (e.g. golang -> go) before routing
+	request.Language = NormalizeLanguage(request.Language)
+
+	var tokens []SyntaxToken
+	switch strings.ToLower(request.Language) {
+	case "go":
+		tokens = tokenizeGo(request.Code)
+	default:
+		tokens = tokenizeGeneric(request.Code, request.Language)
+	}
+
+	if tokens == nil {
+		tokens = []SyntaxToken{}
+	}
+	return &TokenizeResponse{Tokens: tokens}, nil
+}
+
+// tokenizeGo classifies Go tokens with the standard library scanner. The
+// scanner tolerates syntax errors, so partial or invalid files still yield
+// usable tokens.
+func tokenizeGo(code string) []SyntaxToken {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(code))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(code), nil, scanner.ScanComments)
+
+	var tokens []SyntaxToken
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// Skip the scanner's automatically inserted semicolons
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+
+		tokenType := classifyGoToken(tok)
+		if tokenType == "" {
+			continue
+		}
+
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		position := file.Position(pos)
+		tokens = append(tokens, SyntaxToken{
+			Line:      position.Line,
+			StartCol:  position.Column,
+			Length:    len(text),
+			TokenType: tokenType,
+		})
+	}
+	return tokens
+}
+
+// classifyGoToken maps a Go token to its semantic token type; unclassified
+// tokens return the empty string and are omitted
+func classifyGoToken(tok token.Token) string {
+	switch {
+	case tok.IsKeyword():
+		return "keyword"
+	case tok == token.IDENT:
+		return "variable"
+	case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+		return "number"
+	case tok == token.STRING || tok == token.CHAR:
+		return "string"
+	case tok == token.COMMENT:
+		return "comment"
+	case tok.IsOperator():
+		return "operator"
+	}
+	return ""
+}
+
+// lineCommentPrefix returns the line comment marker for a language
+func lineCommentPrefix(language string) string {
+	switch language {
+	case "python":
+		return "#"
+	case "sql":
+		return "--"
+	default:
+		return "//"
+	}
+}
+
+// keywordSetFor returns the keyword lookup set for a language, derived from
+// the completion keyword lists. Multi-word SQL keywords contribute each word.
+func keywordSetFor(language string) map[string]bool {
+	var keywords []string
+	switch language {
+	case "python":
+		keywords = pythonCompletionKeywords
+	case "sql":
+		keywords = sqlCompletionKeywords
+	default:
+		keywords = genericCompletionConstructs
+	}
+
+	set := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		for _, word := range strings.Fields(keyword) {
+			set[strings.ToLower(word)] = true
+		}
+	}
+	return set
+}
+
+// tokenizeGeneric classifies tokens with a simple line-by-line lexer:
+// comments run to the end of the line, strings to the closing quote, and
+// words are keywords or variables depending on the language's keyword set
+func tokenizeGeneric(code, language string) []SyntaxToken {
+	commentPrefix := lineCommentPrefix(language)
+	keywords := keywordSetFor(language)
+
+	var tokens []SyntaxToken
+	for lineIdx, line := range splitLines(code) {
+		col := 0
+		for col < len(line) {
+			c := line[col]
+			switch {
+			case c == ' ' || c == '\t':
+				col++
+			case strings.HasPrefix(line[col:], commentPrefix):
+				tokens = append(tokens, SyntaxToken{
+					Line:      lineIdx + 1,
+					StartCol:  col + 1,
+					Length:    len(line) - col,
+					TokenType: "comment",
+				})
+				col = len(line)
+			case c == '"' || c == '\'':
+				end := col + 1
+				for end < len(line) && line[end] != c {
+					if line[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				if end < len(line) {
+					end++
+				}
+				tokens = append(tokens, SyntaxToken{
+					Line:      lineIdx + 1,
+					StartCol:  col + 1,
+					Length:    end - col,
+					TokenType: "string",
+				})
+				col = end
+			case c >= '0' && c <= '9':
+				end := col
+				for end < len(line) && (isWordByte(line[end]) || line[end] == '.') {
+					end++
+				}
+				tokens = append(tokens, SyntaxToken{
+					Line:      lineIdx + 1,
+					StartCol:  col + 1,
+					Length:    end - col,
+					TokenType: "number",
+				})
+				col = end
+			case isWordByte(c) && !(c >= '0' && c <= '9'):
+				end := col
+				for end < len(line) && isWordByte(line[end]) {
+					end++
+				}
+				tokenType := "variable"
+				if keywords[strings.ToLower(line[col:end])] {
+					tokenType = "keyword"
+				}
+				tokens = append(tokens, SyntaxToken{
+					Line:      lineIdx + 1,
+					StartCol:  col + 1,
+					Length:    end - col,
+					TokenType: tokenType,
+				})
+				col = end
+			default:
+				tokens = append(tokens, SyntaxToken{
+					Line:      lineIdx + 1,
+					StartCol:  col + 1,
+					Length:    1,
+					TokenType: "operator",
+				})
+				col++
+			}
+		}
+	}
+	return tokens
+}
+
+// isWordByte reports whether a byte can appear inside an identifier
+func isWordByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
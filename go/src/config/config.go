@@ -2,29 +2,97 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 // Config holds all configuration for the MCP server
 type Config struct {
-	Server     ServerConfig     `mapstructure:"server"`
-	MCP        MCPConfig        `mapstructure:"mcp"`
-	Logging    LoggingConfig    `mapstructure:"logging"`
-	Monitoring MonitoringConfig `mapstructure:"monitoring"`
-	Database   DatabaseConfig   `mapstructure:"database"`
+	Server        ServerConfig        `mapstructure:"server"`
+	MCP           MCPConfig           `mapstructure:"mcp"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	Monitoring    MonitoringConfig    `mapstructure:"monitoring"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Analysis      AnalysisConfig      `mapstructure:"analysis"`
+	Audit         AuditConfig         `mapstructure:"audit"`
+	Documentation DocumentationConfig `mapstructure:"documentation"`
+	Completion    CompletionConfig    `mapstructure:"completion"`
+
+	// UnknownKeys lists config-file keys that matched no known field, so the
+	// caller can warn about probable typos. Populated at load, never read
+	// from the file.
+	UnknownKeys []string `mapstructure:"-"`
+}
+
+// CompletionConfig holds code completion configuration
+type CompletionConfig struct {
+	// Keywords is the path to a JSON or YAML file mapping languages to
+	// extra completion keywords merged over the built-in sets
+	Keywords string `mapstructure:"keywords"`
+	// soft deadline after which partial results are returned ("0s" disables)
+	Timeout string `mapstructure:"timeout"`
+	// Popularity overrides the built-in completion frequency table, mapping
+	// completion labels to 0-100 weights that influence sort order
+	Popularity map[string]int `mapstructure:"popularity"`
+}
+
+// DocumentationConfig holds documentation configuration
+type DocumentationConfig struct {
+	Sources []string `mapstructure:"sources"`
+	// size cap for the documentation lookup cache
+	CacheMaxEntries int `mapstructure:"cache_max_entries"`
+}
+
+// AuditConfig holds tool-execution audit log configuration
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Output  string `mapstructure:"output"`
+	File    string `mapstructure:"file"`
+}
+
+// AnalysisConfig holds code analysis configuration
+type AnalysisConfig struct {
+	SeverityOverrides map[string]string `mapstructure:"severity_overrides"`
+	DisabledRules     []string          `mapstructure:"disabled_rules"`
+	MaxLineLength     int               `mapstructure:"max_line_length"`
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port        int      `mapstructure:"port"`
-	Host        string   `mapstructure:"host"`
-	MetricsPort int      `mapstructure:"metrics_port"`
-	LogLevel    string   `mapstructure:"log_level"`
-	Debug       bool     `mapstructure:"debug"`
-	CORS        CORSConfig `mapstructure:"cors"`
+	Port        int    `mapstructure:"port"`
+	Host        string `mapstructure:"host"`
+	MetricsPort int    `mapstructure:"metrics_port"`
+	LogLevel    string `mapstructure:"log_level"`
+	Debug       bool   `mapstructure:"debug"`
+	SafeMode    bool   `mapstructure:"safe_mode"`
+	// StrictConfig turns unknown config-file keys into a load error instead
+	// of a warning, so a typo'd key cannot silently fall back to its default
+	StrictConfig bool `mapstructure:"strict_config"`
+	// path prefix for every route on the main server, for deployments
+	// behind a path-based ingress (e.g. "/mcp"); empty serves from the root
+	BasePath string `mapstructure:"base_path"`
+	// feature flags enabled for every request, merged with each request's
+	// X-Features header
+	Features []string `mapstructure:"features"`
+	// proxies whose X-Forwarded-For headers are trusted for client IPs
+	TrustedProxies  []string       `mapstructure:"trusted_proxies"`
+	ShutdownTimeout string         `mapstructure:"shutdown_timeout"`
+	Timeouts        TimeoutsConfig `mapstructure:"timeouts"`
+	CORS            CORSConfig     `mapstructure:"cors"`
+}
+
+// TimeoutsConfig holds HTTP server connection timeouts
+type TimeoutsConfig struct {
+	Read       string `mapstructure:"read"`
+	ReadHeader string `mapstructure:"read_header"`
+	Write      string `mapstructure:"write"`
+	Idle       string `mapstructure:"idle"`
 }
 
 // CORSConfig holds CORS configuration
@@ -34,12 +102,72 @@ type CORSConfig struct {
 	AllowedHeaders []string `mapstructure:"allowed_headers"`
 }
 
+// defaultAllowedHeaders is the concrete header set a wildcard expands to.
+// Browsers reject "*" in Access-Control-Allow-Headers when credentials are
+// enabled, so the wildcard must never reach the wire.
+var defaultAllowedHeaders = []string{
+	"Accept",
+	"Authorization",
+	"Content-Type",
+	"Idempotency-Key",
+	"Mcp-Session-Id",
+	"Origin",
+	"X-API-Key",
+	"X-Request-ID",
+}
+
+// EffectiveAllowedHeaders returns the configured allowed headers with any
+// "*" entry expanded into the concrete default set, keeping extra configured
+// headers and dropping duplicates
+func (c CORSConfig) EffectiveAllowedHeaders() []string {
+	headers := []string{}
+	seen := map[string]bool{}
+	add := func(header string) {
+		key := strings.ToLower(header)
+		if !seen[key] {
+			seen[key] = true
+			headers = append(headers, header)
+		}
+	}
+
+	for _, header := range c.AllowedHeaders {
+		if header == "*" {
+			for _, expanded := range defaultAllowedHeaders {
+				add(expanded)
+			}
+			continue
+		}
+		add(header)
+	}
+
+	return headers
+}
+
 // MCPConfig holds MCP protocol configuration
 type MCPConfig struct {
-	ProtocolVersion string            `mapstructure:"protocol_version"`
-	ServerName      string            `mapstructure:"server_name"`
-	ServerVersion   string            `mapstructure:"server_version"`
-	Capabilities    CapabilitiesConfig `mapstructure:"capabilities"`
+	ProtocolVersion        string             `mapstructure:"protocol_version"`
+	ServerName             string             `mapstructure:"server_name"`
+	ServerVersion          string             `mapstructure:"server_version"`
+	UpstreamURL            string             `mapstructure:"upstream_url"`
+	UpstreamTimeout        string             `mapstructure:"upstream_timeout"`
+	LanguageAliases        map[string]string  `mapstructure:"language_aliases"`
+	LanguageExtensions     map[string]string  `mapstructure:"language_extensions"`
+	SSEEnabled             bool               `mapstructure:"sse_enabled"`
+	SSEIdleTimeout         string             `mapstructure:"sse_idle_timeout"`
+	MaxCompletionBytes     int                `mapstructure:"max_completion_bytes"`
+	MaxConcurrentToolCalls int                `mapstructure:"max_concurrent_tool_calls"`
+	StrictParams           bool               `mapstructure:"strict_params"`
+	Idempotency            IdempotencyConfig  `mapstructure:"idempotency"`
+	Capabilities           CapabilitiesConfig `mapstructure:"capabilities"`
+}
+
+// IdempotencyConfig holds the optional tool-call idempotency cache settings.
+// When enabled, a request carrying an idempotency key replays the cached
+// response instead of re-executing the tool.
+type IdempotencyConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`
+	TTL        string `mapstructure:"ttl"`
+	MaxEntries int    `mapstructure:"max_entries"`
 }
 
 // CapabilitiesConfig holds MCP capabilities configuration
@@ -69,17 +197,47 @@ type MonitoringConfig struct {
 	Prometheus          bool   `mapstructure:"prometheus"`
 	HealthCheckInterval string `mapstructure:"health_check_interval"`
 	HealthCheckTimeout  string `mapstructure:"health_check_timeout"`
+	// window during which a successful dependency check is reused ("0s" disables)
+	HealthCheckCache string        `mapstructure:"health_check_cache"`
+	Metrics          MetricsConfig `mapstructure:"metrics"`
+}
+
+// MetricsConfig holds Prometheus metric naming configuration
+type MetricsConfig struct {
+	Namespace   string            `mapstructure:"namespace"`
+	ConstLabels map[string]string `mapstructure:"const_labels"`
+	Auth        MetricsAuthConfig `mapstructure:"auth"`
+}
+
+// MetricsAuthConfig holds optional access control for the metrics endpoint.
+// When neither field is set the endpoint stays open.
+type MetricsAuthConfig struct {
+	BearerToken string   `mapstructure:"bearer_token"`
+	AllowedIPs  []string `mapstructure:"allowed_ips"`
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	ConnectionString string `mapstructure:"connection_string"`
-	MaxConnections   int    `mapstructure:"max_connections"`
-	ConnectionTimeout string `mapstructure:"connection_timeout"`
-	IdleTimeout      string `mapstructure:"idle_timeout"`
+	ConnectionString  string              `mapstructure:"connection_string"`
+	MaxConnections    int                 `mapstructure:"max_connections"`
+	ConnectionTimeout string              `mapstructure:"connection_timeout"`
+	IdleTimeout       string              `mapstructure:"idle_timeout"`
+	AllowedVerbs      map[string][]string `mapstructure:"allowed_verbs"`
+	Discovery         DiscoveryConfig     `mapstructure:"discovery"`
 }
 
-// LoadConfig loads the configuration from file and environment variables
+// DiscoveryConfig holds defaults for the discover_data tool. Per-table sample
+// sizes override the global default.
+type DiscoveryConfig struct {
+	SampleSize       int            `mapstructure:"sample_size"`
+	Stats            bool           `mapstructure:"stats"`
+	TableSampleSizes map[string]int `mapstructure:"table_sample_sizes"`
+}
+
+// LoadConfig loads the configuration from file and environment variables.
+// Precedence, lowest to highest: built-in defaults, config.yaml, the
+// config.<env>.yaml overlay selected by APP_ENV (or ENV), then environment
+// variables.
 func LoadConfig(path string) (*Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -97,6 +255,17 @@ func LoadConfig(path string) (*Config, error) {
 		}
 	}
 
+	// Merge the environment-specific overlay over the base file, when one is
+	// selected. A selected but missing overlay is tolerated, like the base.
+	if env := environmentName(); env != "" {
+		viper.SetConfigName("config." + env)
+		if err := viper.MergeInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return nil, fmt.Errorf("error reading config overlay for %s: %w", env, err)
+			}
+		}
+	}
+
 	// Override with environment variables
 	overrideWithEnv()
 
@@ -105,9 +274,239 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("unable to decode config into struct: %w", err)
 	}
 
+	// Validate the configuration
+	if err := validateConfig(&config); err != nil {
+		return nil, err
+	}
+
+	// Surface config-file keys that match no known field, so a typo'd key
+	// (serevr.port) does not silently fall back to its default
+	config.UnknownKeys = unknownConfigKeys(configFileKeys(path))
+	if config.Server.StrictConfig && len(config.UnknownKeys) > 0 {
+		return nil, fmt.Errorf("unknown configuration keys: %s", strings.Join(config.UnknownKeys, ", "))
+	}
+
 	return &config, nil
 }
 
+// configFileKeys returns the dotted keys actually present in the config file
+// and its environment overlay, read with a clean viper instance so defaults
+// and environment variables are not mixed in. Missing files yield no keys,
+// matching LoadConfig's tolerance.
+func configFileKeys(path string) []string {
+	v := viper.New()
+	v.SetConfigName("config")
+	v.SetConfigType("yaml")
+	v.AddConfigPath(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	if env := environmentName(); env != "" {
+		v.SetConfigName("config." + env)
+		if err := v.MergeInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return v.AllKeys()
+			}
+		}
+	}
+	return v.AllKeys()
+}
+
+// unknownConfigKeys filters fileKeys down to those matching no mapstructure
+// field of Config. Map-typed fields accept arbitrary child keys, so anything
+// under their prefix is known.
+func unknownConfigKeys(fileKeys []string) []string {
+	known, mapPrefixes := knownConfigKeys()
+
+	var unknown []string
+	for _, key := range fileKeys {
+		if known[key] {
+			continue
+		}
+		underMap := false
+		for _, prefix := range mapPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				underMap = true
+				break
+			}
+		}
+		if !underMap {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// knownConfigKeys walks the Config struct's mapstructure tags and returns
+// every reachable dotted key path, plus the prefixes of map-typed fields
+// whose child keys are caller-defined
+func knownConfigKeys() (map[string]bool, []string) {
+	known := map[string]bool{}
+	var mapPrefixes []string
+
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("mapstructure")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			path := tag
+			if prefix != "" {
+				path = prefix + "." + tag
+			}
+			switch field.Type.Kind() {
+			case reflect.Struct:
+				walk(field.Type, path)
+			case reflect.Map:
+				known[path] = true
+				mapPrefixes = append(mapPrefixes, path+".")
+			default:
+				known[path] = true
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+
+	return known, mapPrefixes
+}
+
+// environmentName returns the deployment environment selecting a config
+// overlay, preferring APP_ENV over ENV
+func environmentName() string {
+	if env := os.Getenv("APP_ENV"); env != "" {
+		return env
+	}
+	return os.Getenv("ENV")
+}
+
+// validSeverities is the set of severities accepted for analysis diagnostics
+var validSeverities = map[string]bool{
+	"error":   true,
+	"warning": true,
+	"info":    true,
+	"hint":    true,
+}
+
+// validateConfig validates the loaded configuration
+func validateConfig(config *Config) error {
+	// The main and metrics listeners need their own ports; catching a clash
+	// here beats a confusing "address already in use" after the first bind
+	// succeeds
+	for name, port := range map[string]int{
+		"server.port":         config.Server.Port,
+		"server.metrics_port": config.Server.MetricsPort,
+	} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid %s: %d is not a valid port", name, port)
+		}
+	}
+	if config.Server.Port == config.Server.MetricsPort {
+		return fmt.Errorf("server.port and server.metrics_port must differ (both are %d)", config.Server.Port)
+	}
+
+	// Validate analysis severity overrides
+	for ruleID, severity := range config.Analysis.SeverityOverrides {
+		if !validSeverities[severity] {
+			return fmt.Errorf("invalid severity %q for analysis rule %q: must be one of error, warning, info, hint", severity, ruleID)
+		}
+	}
+
+	if config.Analysis.MaxLineLength <= 0 {
+		return fmt.Errorf("invalid analysis.max_line_length: must be positive")
+	}
+
+	if config.Documentation.CacheMaxEntries <= 0 {
+		return fmt.Errorf("invalid documentation.cache_max_entries: must be positive")
+	}
+
+	if _, err := time.ParseDuration(config.Completion.Timeout); err != nil {
+		return fmt.Errorf("invalid completion.timeout: %w", err)
+	}
+
+	for label, weight := range config.Completion.Popularity {
+		if weight < 0 || weight > 100 {
+			return fmt.Errorf("invalid completion.popularity weight %d for %q: must be between 0 and 100", weight, label)
+		}
+	}
+
+	if config.Server.BasePath != "" {
+		if !strings.HasPrefix(config.Server.BasePath, "/") {
+			return fmt.Errorf("invalid server.base_path: must start with '/'")
+		}
+		if strings.HasSuffix(config.Server.BasePath, "/") {
+			return fmt.Errorf("invalid server.base_path: must not end with '/'")
+		}
+	}
+
+	// Trusted proxies must be valid IPs or CIDR ranges
+	for _, proxy := range config.Server.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				return fmt.Errorf("invalid server.trusted_proxies entry %q: must be an IP or CIDR", proxy)
+			}
+		}
+	}
+
+	// Validate server durations
+	if _, err := time.ParseDuration(config.Server.ShutdownTimeout); err != nil {
+		return fmt.Errorf("invalid server.shutdown_timeout: %w", err)
+	}
+	for name, value := range map[string]string{
+		"server.timeouts.read":        config.Server.Timeouts.Read,
+		"server.timeouts.read_header": config.Server.Timeouts.ReadHeader,
+		"server.timeouts.write":       config.Server.Timeouts.Write,
+		"server.timeouts.idle":        config.Server.Timeouts.Idle,
+	} {
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+
+	// Validate upstream gateway settings
+	if config.MCP.UpstreamTimeout != "" {
+		if _, err := time.ParseDuration(config.MCP.UpstreamTimeout); err != nil {
+			return fmt.Errorf("invalid mcp.upstream_timeout: %w", err)
+		}
+	}
+
+	// Validate SSE settings
+	if config.MCP.SSEIdleTimeout != "" {
+		if _, err := time.ParseDuration(config.MCP.SSEIdleTimeout); err != nil {
+			return fmt.Errorf("invalid mcp.sse_idle_timeout: %w", err)
+		}
+	}
+	if config.MCP.MaxCompletionBytes < 0 {
+		return fmt.Errorf("invalid mcp.max_completion_bytes: must not be negative")
+	}
+	if config.MCP.MaxConcurrentToolCalls < 0 {
+		return fmt.Errorf("invalid mcp.max_concurrent_tool_calls: must not be negative")
+	}
+	if config.MCP.Idempotency.Enabled {
+		if _, err := time.ParseDuration(config.MCP.Idempotency.TTL); err != nil {
+			return fmt.Errorf("invalid mcp.idempotency.ttl: %w", err)
+		}
+		if config.MCP.Idempotency.MaxEntries <= 0 {
+			return fmt.Errorf("invalid mcp.idempotency.max_entries: must be positive")
+		}
+	}
+
+	// Validate monitoring durations
+	if _, err := time.ParseDuration(config.Monitoring.HealthCheckInterval); err != nil {
+		return fmt.Errorf("invalid monitoring.health_check_interval: %w", err)
+	}
+	if _, err := time.ParseDuration(config.Monitoring.HealthCheckTimeout); err != nil {
+		return fmt.Errorf("invalid monitoring.health_check_timeout: %w", err)
+	}
+	if _, err := time.ParseDuration(config.Monitoring.HealthCheckCache); err != nil {
+		return fmt.Errorf("invalid monitoring.health_check_cache: %w", err)
+	}
+
+	return nil
+}
+
 // setDefaults sets default values for configuration
 func setDefaults() {
 	// Server defaults
@@ -116,6 +515,16 @@ func setDefaults() {
 	viper.SetDefault("server.metrics_port", 9091)
 	viper.SetDefault("server.log_level", "info")
 	viper.SetDefault("server.debug", false)
+	viper.SetDefault("server.safe_mode", false)
+	viper.SetDefault("server.strict_config", false)
+	viper.SetDefault("server.features", []string{})
+	viper.SetDefault("server.base_path", "")
+	viper.SetDefault("server.trusted_proxies", []string{"127.0.0.1", "::1"})
+	viper.SetDefault("server.shutdown_timeout", "10s")
+	viper.SetDefault("server.timeouts.read", "30s")
+	viper.SetDefault("server.timeouts.read_header", "10s")
+	viper.SetDefault("server.timeouts.write", "30s")
+	viper.SetDefault("server.timeouts.idle", "120s")
 	viper.SetDefault("server.cors.allowed_origins", []string{"*"})
 	viper.SetDefault("server.cors.allowed_methods", []string{"GET", "POST", "OPTIONS"})
 	viper.SetDefault("server.cors.allowed_headers", []string{"*"})
@@ -124,6 +533,16 @@ func setDefaults() {
 	viper.SetDefault("mcp.protocol_version", "2.0")
 	viper.SetDefault("mcp.server_name", "Go MCP Server")
 	viper.SetDefault("mcp.server_version", "1.0.0")
+	viper.SetDefault("mcp.upstream_url", "")
+	viper.SetDefault("mcp.upstream_timeout", "30s")
+	viper.SetDefault("mcp.sse_enabled", true)
+	viper.SetDefault("mcp.sse_idle_timeout", "0s")
+	viper.SetDefault("mcp.max_completion_bytes", 0)
+	viper.SetDefault("mcp.max_concurrent_tool_calls", 0)
+	viper.SetDefault("mcp.strict_params", false)
+	viper.SetDefault("mcp.idempotency.enabled", false)
+	viper.SetDefault("mcp.idempotency.ttl", "60s")
+	viper.SetDefault("mcp.idempotency.max_entries", 1024)
 	viper.SetDefault("mcp.capabilities.supported_languages", []string{"go", "sql"})
 	viper.SetDefault("mcp.capabilities.supports_notebooks", true)
 	viper.SetDefault("mcp.capabilities.supports_streaming", true)
@@ -136,16 +555,35 @@ func setDefaults() {
 	viper.SetDefault("logging.rotation.max_backups", 3)
 	viper.SetDefault("logging.rotation.max_age", 7)
 
+	// Analysis defaults
+	viper.SetDefault("analysis.max_line_length", 100)
+
+	// Completion defaults
+	viper.SetDefault("completion.keywords", "")
+	viper.SetDefault("completion.timeout", "0s")
+	viper.SetDefault("completion.popularity", map[string]int{})
+
+	// Documentation defaults
+	viper.SetDefault("documentation.cache_max_entries", 512)
+
+	// Audit defaults
+	viper.SetDefault("audit.enabled", false)
+	viper.SetDefault("audit.output", "stdout")
+
 	// Monitoring defaults
 	viper.SetDefault("monitoring.prometheus", true)
 	viper.SetDefault("monitoring.health_check_interval", "30s")
 	viper.SetDefault("monitoring.health_check_timeout", "10s")
+	viper.SetDefault("monitoring.health_check_cache", "5s")
+	viper.SetDefault("monitoring.metrics.namespace", "mcp")
 
 	// Database defaults
 	viper.SetDefault("database.connection_string", "postgres://demo:demo@db:5432/demo?sslmode=disable")
 	viper.SetDefault("database.max_connections", 10)
 	viper.SetDefault("database.connection_timeout", "5s")
 	viper.SetDefault("database.idle_timeout", "60s")
+	viper.SetDefault("database.discovery.sample_size", 10)
+	viper.SetDefault("database.discovery.stats", true)
 }
 
 // overrideWithEnv overrides configuration with environment variables
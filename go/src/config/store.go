@@ -0,0 +1,47 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Store holds the live configuration and supports safe concurrent reloads.
+// Reloads are serialized by a mutex, and the active *Config is swapped
+// atomically only after it has been fully loaded and validated, so readers
+// never observe a partially-applied configuration.
+type Store struct {
+	path     string
+	reloadMu sync.Mutex
+	current  atomic.Pointer[Config]
+}
+
+// NewStore loads the configuration from path and returns a store holding it
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path}
+	if _, err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Current returns the active configuration. The returned pointer is a
+// complete snapshot; a concurrent reload swaps in a new pointer rather than
+// mutating this one.
+func (s *Store) Current() *Config {
+	return s.current.Load()
+}
+
+// Reload re-reads the configuration from disk and swaps it in. Concurrent
+// reloads are serialized; on error the previous configuration stays active.
+func (s *Store) Reload() (*Config, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	config, err := LoadConfig(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.current.Store(config)
+	return config, nil
+}
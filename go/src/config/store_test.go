@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// writeConfigFile writes a minimal config.yaml carrying the given port into dir
+func writeConfigFile(t *testing.T, dir string, port int) {
+	t.Helper()
+	content := fmt.Sprintf("server:\n  port: %d\n", port)
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+}
+
+func TestStoreReloadSwapsConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, 8080)
+
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	if port := store.Current().Server.Port; port != 8080 {
+		t.Fatalf("expected port 8080, got %d", port)
+	}
+
+	writeConfigFile(t, dir, 8180)
+	if _, err := store.Reload(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if port := store.Current().Server.Port; port != 8180 {
+		t.Fatalf("expected port 8180 after reload, got %d", port)
+	}
+}
+
+func TestStoreConcurrentReadsDuringReload(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, 8080)
+
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+
+	// Hammer Current while reloads land; under -race this catches torn reads,
+	// and every snapshot must carry one of the two complete configurations
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				cfg := store.Current()
+				if port := cfg.Server.Port; port != 8080 && port != 8180 {
+					t.Errorf("observed torn config: port %d", port)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		port := 8080
+		if i%2 == 1 {
+			port = 8180
+		}
+		writeConfigFile(t, dir, port)
+		if _, err := store.Reload(); err != nil {
+			t.Fatalf("Reload failed: %v", err)
+		}
+	}
+
+	close(stop)
+	wg.Wait()
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Client wraps the SQL database connection used by the SQL tools
+type Client struct {
+	db           *sql.DB
+	driver       string
+	logger       *logrus.Logger
+	allowedVerbs map[string][]string
+	discovery    config.DiscoveryConfig
+}
+
+// driverForDSN maps a connection string to the registered driver name
+func driverForDSN(dsn string) (string, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return "postgres", nil
+	case strings.HasPrefix(dsn, "mysql://"):
+		return "mysql", nil
+	case strings.HasPrefix(dsn, "sqlite://"), strings.HasPrefix(dsn, "sqlite3://"):
+		return "sqlite3", nil
+	default:
+		// Key-value DSNs (host=... dbname=...) are a Postgres convention
+		if strings.Contains(dsn, "=") && !strings.Contains(dsn, "://") {
+			return "postgres", nil
+		}
+		return "", fmt.Errorf("cannot determine database driver from connection string")
+	}
+}
+
+// driverCompiledIn reports whether the named driver was registered by one of
+// the blank imports compiled into this binary
+func driverCompiledIn(driver string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			return true
+		}
+	}
+	return false
+}
+
+// NewClient creates a new database client from configuration.
+// The connection is established lazily on first use.
+func NewClient(cfg *config.DatabaseConfig, logger *logrus.Logger) (*Client, error) {
+	driver, err := driverForDSN(cfg.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drivers register through blank imports, some behind build tags, so the
+	// DSN can name a driver this binary was built without. Catch that here
+	// with a clear startup error instead of an opaque one at first query.
+	if !driverCompiledIn(driver) {
+		return nil, fmt.Errorf("driver %q not compiled in; compiled-in drivers: %s", driver, strings.Join(sql.Drivers(), ", "))
+	}
+
+	dsn := cfg.ConnectionString
+	// database/sql's mysql driver expects the DSN without the scheme
+	if driver == "mysql" {
+		dsn = strings.TrimPrefix(dsn, "mysql://")
+	}
+	// The sqlite driver takes a bare file path
+	if driver == "sqlite3" {
+		dsn = strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite3://"), "sqlite://")
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		// Never propagate the raw DSN; driver errors can echo it back
+		return nil, fmt.Errorf("failed to open database %s: %w", redactDSN(dsn), err)
+	}
+
+	logger.WithField("dsn", redactDSN(dsn)).Info("Database client configured")
+
+	if cfg.MaxConnections > 0 {
+		db.SetMaxOpenConns(cfg.MaxConnections)
+	}
+	if idleTimeout, err := time.ParseDuration(cfg.IdleTimeout); err == nil {
+		db.SetConnMaxIdleTime(idleTimeout)
+	}
+
+	client := &Client{
+		db:        db,
+		driver:    driver,
+		logger:    logger,
+		discovery: cfg.Discovery,
+	}
+	client.SetAllowedVerbs(cfg.AllowedVerbs)
+
+	return client, nil
+}
+
+// Driver returns the name of the SQL driver in use
+func (c *Client) Driver() string {
+	return c.driver
+}
+
+// Ping verifies the database connection
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.db.PingContext(ctx); err != nil {
+		c.logger.WithError(err).Warn("Database ping failed")
+		return err
+	}
+	return nil
+}
+
+// Close closes the database connection
+func (c *Client) Close() error {
+	return c.db.Close()
+}
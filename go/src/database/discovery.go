@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// tableNameRegex restricts discovery to plain identifiers, since the table
+// name is interpolated into the query text
+var tableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// DiscoveryResult represents a data sample taken from a table
+type DiscoveryResult struct {
+	Table    string                   `json:"table"`
+	Columns  []ColumnInfo             `json:"columns"`
+	Sample   []map[string]interface{} `json:"sample"`
+	RowCount *int64                   `json:"rowCount,omitempty"`
+}
+
+// discoverySampleSize resolves the effective sample size for a table: the
+// request value when positive, then any per-table override, then the default
+func (c *Client) discoverySampleSize(table string, requested int) int {
+	if requested > 0 {
+		return requested
+	}
+	if size, ok := c.discovery.TableSampleSizes[table]; ok && size > 0 {
+		return size
+	}
+	if c.discovery.SampleSize > 0 {
+		return c.discovery.SampleSize
+	}
+	return 10
+}
+
+// Discover samples rows from a table and optionally counts them. A nil stats
+// pointer falls back to the configured default.
+func (c *Client) Discover(ctx context.Context, table string, sampleSize int, stats *bool) (*DiscoveryResult, error) {
+	if !tableNameRegex.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name: %s", table)
+	}
+
+	computeStats := c.discovery.Stats
+	if stats != nil {
+		computeStats = *stats
+	}
+
+	size := c.discoverySampleSize(table, sampleSize)
+	sample, err := c.Query(ctx, fmt.Sprintf("SELECT * FROM %s LIMIT %d", table, size))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DiscoveryResult{
+		Table:   table,
+		Columns: sample.Columns,
+		Sample:  sample.Rows,
+	}
+
+	if computeStats {
+		var rowCount int64
+		if err := c.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&rowCount); err != nil {
+			return nil, err
+		}
+		result.RowCount = &rowCount
+	}
+
+	return result, nil
+}
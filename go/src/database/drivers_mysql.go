@@ -0,0 +1,6 @@
+//go:build !nomysql
+
+package database
+
+// Register the MySQL driver. Build with -tags nomysql to exclude it.
+import _ "github.com/go-sql-driver/mysql"
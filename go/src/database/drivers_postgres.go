@@ -0,0 +1,6 @@
+//go:build !nopostgres
+
+package database
+
+// Register the Postgres driver. Build with -tags nopostgres to exclude it.
+import _ "github.com/lib/pq"
@@ -0,0 +1,8 @@
+//go:build sqlite
+
+package database
+
+// The SQLite driver requires cgo, so it is opt-in: build with -tags sqlite.
+// Without it, sqlite:// connection strings fail at startup with a clear
+// "not compiled in" error.
+import _ "github.com/mattn/go-sqlite3"
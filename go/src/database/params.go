@@ -0,0 +1,136 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// namedParamRegex matches :name placeholders, excluding Postgres ::type casts
+var namedParamRegex = regexp.MustCompile(`(^|[^:]):([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+// BindParams accepts either a positional params array or a named params object
+// and rewrites the query into the driver's placeholder style, returning the
+// query and its ordered arguments
+func (c *Client) BindParams(query string, params json.RawMessage) (string, []interface{}, error) {
+	if len(params) == 0 || string(params) == "null" {
+		return query, nil, nil
+	}
+
+	var positional []interface{}
+	if err := json.Unmarshal(params, &positional); err == nil {
+		return c.bindPositional(query, positional)
+	}
+
+	var named map[string]interface{}
+	if err := json.Unmarshal(params, &named); err == nil {
+		return c.bindNamed(query, named)
+	}
+
+	return "", nil, fmt.Errorf("params must be an array or an object")
+}
+
+// placeholder returns the driver's placeholder for the given 1-based position
+func (c *Client) placeholder(position int) string {
+	if c.driver == "postgres" {
+		return fmt.Sprintf("$%d", position)
+	}
+	return "?"
+}
+
+// bindPositional rewrites ?-style placeholders into the driver's style.
+// Question marks inside quoted string literals and identifiers are data,
+// not placeholders, and are left untouched.
+func (c *Client) bindPositional(query string, params []interface{}) (string, []interface{}, error) {
+	var builder strings.Builder
+	count := 0
+	var quote rune
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			// A doubled quote inside a literal toggles out and straight back
+			// in, so tracking only the closing quote handles '' escapes too
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			count++
+			if c.driver == "postgres" {
+				builder.WriteString(c.placeholder(count))
+				continue
+			}
+		}
+		builder.WriteRune(r)
+	}
+
+	if count != len(params) {
+		return "", nil, fmt.Errorf("query has %d placeholders but %d params were provided", count, len(params))
+	}
+
+	if c.driver == "postgres" {
+		query = builder.String()
+	}
+	return query, params, nil
+}
+
+// bindNamed rewrites :name placeholders into the driver's style, ordering the
+// arguments by first appearance
+func (c *Client) bindNamed(query string, params map[string]interface{}) (string, []interface{}, error) {
+	positions := map[string]int{}
+	var args []interface{}
+
+	rewritten := namedParamRegex.ReplaceAllStringFunc(query, func(match string) string {
+		groups := namedParamRegex.FindStringSubmatch(match)
+		prefix, name := groups[1], groups[2]
+
+		value, ok := params[name]
+		if !ok {
+			// Leave the placeholder in place; missing names are reported below
+			return match
+		}
+
+		if c.driver == "postgres" {
+			if position, seen := positions[name]; seen {
+				return prefix + c.placeholder(position)
+			}
+			args = append(args, value)
+			positions[name] = len(args)
+			return prefix + c.placeholder(len(args))
+		}
+
+		args = append(args, value)
+		positions[name] = len(args)
+		return prefix + "?"
+	})
+
+	// Report placeholders with no matching param
+	var missing []string
+	for _, groups := range namedParamRegex.FindAllStringSubmatch(query, -1) {
+		name := groups[2]
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", nil, fmt.Errorf("missing params for placeholders: %s", strings.Join(missing, ", "))
+	}
+
+	// Report provided params that match no placeholder
+	var unused []string
+	for name := range params {
+		if _, ok := positions[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, fmt.Errorf("params do not match any placeholder: %s", strings.Join(unused, ", "))
+	}
+
+	return rewritten, args, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBindPositionalCountsPlaceholders(t *testing.T) {
+	c := &Client{driver: "mysql"}
+
+	query, args, err := c.BindParams("SELECT * FROM users WHERE id = ? AND name = ?", json.RawMessage(`[1, "alice"]`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if query != "SELECT * FROM users WHERE id = ? AND name = ?" {
+		t.Errorf("query was rewritten for a ?-style driver: %q", query)
+	}
+	if len(args) != 2 {
+		t.Errorf("expected 2 args, got %v", args)
+	}
+
+	if _, _, err := c.BindParams("SELECT * FROM users WHERE id = ?", json.RawMessage(`[1, 2]`)); err == nil {
+		t.Error("expected an error for a placeholder/param count mismatch")
+	}
+}
+
+func TestBindPositionalRewritesForPostgres(t *testing.T) {
+	c := &Client{driver: "postgres"}
+
+	query, args, err := c.BindParams("SELECT * FROM users WHERE id = ? AND name = ?", json.RawMessage(`[1, "alice"]`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if query != "SELECT * FROM users WHERE id = $1 AND name = $2" {
+		t.Errorf("unexpected rewrite: %q", query)
+	}
+	if len(args) != 2 {
+		t.Errorf("expected 2 args, got %v", args)
+	}
+}
+
+func TestBindPositionalIgnoresQuotedQuestionMarks(t *testing.T) {
+	// Question marks inside string literals, doubled-quote escapes, and
+	// quoted identifiers are data, not placeholders
+	queries := []string{
+		`SELECT 'a?b' FROM users WHERE id = ?`,
+		`SELECT 'it''s?' FROM users WHERE id = ?`,
+		`SELECT "weird?col" FROM users WHERE id = ?`,
+	}
+
+	mysql := &Client{driver: "mysql"}
+	for _, query := range queries {
+		if _, _, err := mysql.BindParams(query, json.RawMessage(`[1]`)); err != nil {
+			t.Errorf("quoted ? miscounted in %q: %v", query, err)
+		}
+	}
+
+	postgres := &Client{driver: "postgres"}
+	query, _, err := postgres.BindParams(`SELECT 'a?b' FROM users WHERE id = ?`, json.RawMessage(`[1]`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if query != `SELECT 'a?b' FROM users WHERE id = $1` {
+		t.Errorf("quoted ? was rewritten: %q", query)
+	}
+}
+
+func TestBindNamedOrdersByAppearance(t *testing.T) {
+	c := &Client{driver: "mysql"}
+
+	query, args, err := c.BindParams("SELECT * FROM users WHERE name = :name AND age > :age", json.RawMessage(`{"age": 30, "name": "alice"}`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if query != "SELECT * FROM users WHERE name = ? AND age > ?" {
+		t.Errorf("unexpected rewrite: %q", query)
+	}
+	if len(args) != 2 || args[0] != "alice" || args[1] != float64(30) {
+		t.Errorf("args not ordered by appearance: %v", args)
+	}
+}
+
+func TestBindNamedReusesPostgresPositions(t *testing.T) {
+	c := &Client{driver: "postgres"}
+
+	query, args, err := c.BindParams("SELECT * FROM users WHERE name = :name OR nick = :name", json.RawMessage(`{"name": "alice"}`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if query != "SELECT * FROM users WHERE name = $1 OR nick = $1" {
+		t.Errorf("repeated name did not reuse its position: %q", query)
+	}
+	if len(args) != 1 {
+		t.Errorf("expected 1 arg for a repeated name, got %v", args)
+	}
+}
+
+func TestBindNamedLeavesCastsAlone(t *testing.T) {
+	c := &Client{driver: "postgres"}
+
+	query, _, err := c.BindParams("SELECT id::text FROM users WHERE name = :name", json.RawMessage(`{"name": "alice"}`))
+	if err != nil {
+		t.Fatalf("BindParams failed: %v", err)
+	}
+	if !strings.Contains(query, "id::text") {
+		t.Errorf("::type cast was rewritten: %q", query)
+	}
+}
+
+func TestBindNamedReportsMissingAndUnusedParams(t *testing.T) {
+	c := &Client{driver: "mysql"}
+
+	_, _, err := c.BindParams("SELECT * FROM users WHERE name = :name AND age > :age", json.RawMessage(`{"name": "alice"}`))
+	if err == nil || !strings.Contains(err.Error(), "age") {
+		t.Errorf("expected a missing-param error naming age, got %v", err)
+	}
+
+	_, _, err = c.BindParams("SELECT * FROM users WHERE name = :name", json.RawMessage(`{"name": "alice", "extra": 1}`))
+	if err == nil || !strings.Contains(err.Error(), "extra") {
+		t.Errorf("expected an unused-param error naming extra, got %v", err)
+	}
+}
+
+func TestBindParamsPassesThroughWithoutParams(t *testing.T) {
+	c := &Client{driver: "postgres"}
+
+	query, args, err := c.BindParams("SELECT 1", nil)
+	if err != nil || query != "SELECT 1" || args != nil {
+		t.Errorf("expected passthrough, got %q, %v, %v", query, args, err)
+	}
+}
@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// ColumnInfo describes a result column with its database type
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// QueryResult represents the rows and column metadata of an executed query
+type QueryResult struct {
+	Columns  []ColumnInfo             `json:"columns"`
+	Rows     []map[string]interface{} `json:"rows"`
+	RowCount int                      `json:"rowCount"`
+}
+
+// Query executes a query and returns rows with per-column type metadata
+func (c *Client) Query(ctx context.Context, query string, args ...interface{}) (*QueryResult, error) {
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]ColumnInfo, len(columnTypes))
+	for i, columnType := range columnTypes {
+		nullable, _ := columnType.Nullable()
+		columns[i] = ColumnInfo{
+			Name:     columnType.Name(),
+			Type:     columnType.DatabaseTypeName(),
+			Nullable: nullable,
+		}
+	}
+
+	result := &QueryResult{
+		Columns: columns,
+		Rows:    []map[string]interface{}{},
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			row[column.Name] = marshalValue(values[i])
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result.RowCount = len(result.Rows)
+	return result, nil
+}
+
+// WriteCSV writes the result as CSV with a header row of column names. Nulls
+// render as empty fields; everything else uses its default string form.
+func (r *QueryResult) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	header := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		header[i] = column.Name
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(r.Columns))
+	for _, row := range r.Rows {
+		for i, column := range r.Columns {
+			value := row[column.Name]
+			if value == nil {
+				record[i] = ""
+				continue
+			}
+			record[i] = fmt.Sprint(value)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ValidationResult represents the outcome of validating a query without executing it
+type ValidationResult struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors"`
+}
+
+// Validate checks a query's syntax and referenced objects by preparing it on
+// the server without executing it
+func (c *Client) Validate(ctx context.Context, query string) (*ValidationResult, error) {
+	statement, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return &ValidationResult{
+			Valid:  false,
+			Errors: []string{err.Error()},
+		}, nil
+	}
+	defer statement.Close()
+
+	return &ValidationResult{
+		Valid:  true,
+		Errors: []string{},
+	}, nil
+}
+
+// marshalValue converts a scanned SQL value into a JSON-friendly representation:
+// timestamps as RFC3339, byte slices as text or base64, and nulls as nil
+func marshalValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultAllowedVerbs maps each SQL tool to the statement verbs it may execute
+// when no configuration is provided. The query tool is read-only but allows
+// CTEs and introspection statements.
+var defaultAllowedVerbs = map[string][]string{
+	"query":   {"SELECT", "WITH", "EXPLAIN", "SHOW"},
+	"execute": {"INSERT", "UPDATE", "DELETE"},
+}
+
+// ClassifyStatement returns the leading SQL verb of a statement after
+// stripping comments and whitespace, uppercased
+func ClassifyStatement(query string) string {
+	remaining := query
+
+	for {
+		remaining = strings.TrimLeft(remaining, " \t\r\n;")
+
+		// Strip line comments
+		if strings.HasPrefix(remaining, "--") {
+			if idx := strings.Index(remaining, "\n"); idx != -1 {
+				remaining = remaining[idx+1:]
+				continue
+			}
+			return ""
+		}
+
+		// Strip block comments
+		if strings.HasPrefix(remaining, "/*") {
+			if idx := strings.Index(remaining, "*/"); idx != -1 {
+				remaining = remaining[idx+2:]
+				continue
+			}
+			return ""
+		}
+
+		break
+	}
+
+	fields := strings.Fields(remaining)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return strings.ToUpper(fields[0])
+}
+
+// SetAllowedVerbs overrides the allowed statement verbs for the given tools
+func (c *Client) SetAllowedVerbs(verbs map[string][]string) {
+	if len(verbs) == 0 {
+		return
+	}
+
+	merged := make(map[string][]string, len(defaultAllowedVerbs)+len(verbs))
+	for tool, toolVerbs := range defaultAllowedVerbs {
+		merged[tool] = toolVerbs
+	}
+	for tool, toolVerbs := range verbs {
+		upper := make([]string, len(toolVerbs))
+		for i, verb := range toolVerbs {
+			upper[i] = strings.ToUpper(verb)
+		}
+		merged[tool] = upper
+	}
+	c.allowedVerbs = merged
+}
+
+// CheckStatementAllowed verifies the statement's leading verb is permitted for
+// the given tool
+func (c *Client) CheckStatementAllowed(tool, query string) error {
+	verbs := c.allowedVerbs
+	if verbs == nil {
+		verbs = defaultAllowedVerbs
+	}
+
+	allowed, ok := verbs[tool]
+	if !ok {
+		return fmt.Errorf("no statement verbs configured for tool %q", tool)
+	}
+
+	verb := ClassifyStatement(query)
+	if verb == "" {
+		return fmt.Errorf("could not determine the statement type")
+	}
+
+	for _, candidate := range allowed {
+		if verb == candidate {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("statement verb %s is not allowed for the %s tool", verb, tool)
+}
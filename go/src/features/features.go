@@ -0,0 +1,74 @@
+// Package features implements request-scoped feature flags: a comma-separated
+// X-Features header enables experimental behavior for a single request, and
+// configured defaults (server.features) enable it for every request. New
+// behavior can roll out gradually this way, without a config change per step.
+package features
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request header listing enabled feature names
+const Header = "X-Features"
+
+// Known feature names. The parser accepts any name; consumers gate on these.
+const (
+	// StrictParams enables strict parameter decoding (reject unknown
+	// fields) for the request, ahead of flipping mcp.strict_params
+	StrictParams = "strict-params"
+	// LSPCompletions makes the LSP CompletionList shape the default
+	// completion encoding for the request
+	LSPCompletions = "lsp-completions"
+)
+
+// ctxKey keys the enabled set in a request context
+type ctxKey struct{}
+
+// Middleware parses the X-Features header into the request context, merged
+// over the configured defaults. The set is attached to the underlying
+// http.Request context too, so call paths that only carry a context.Context
+// can consult it.
+func Middleware(defaults []string) gin.HandlerFunc {
+	base := make(map[string]bool, len(defaults))
+	for _, name := range defaults {
+		if name = strings.TrimSpace(name); name != "" {
+			base[name] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		header := c.GetHeader(Header)
+		if header == "" && len(base) == 0 {
+			c.Next()
+			return
+		}
+
+		enabled := make(map[string]bool, len(base)+4)
+		for name := range base {
+			enabled[name] = true
+		}
+		for _, name := range strings.Split(header, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				enabled[name] = true
+			}
+		}
+
+		ctx := context.WithValue(c.Request.Context(), ctxKey{}, enabled)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// Enabled reports whether the named feature is on for this request
+func Enabled(c *gin.Context, name string) bool {
+	return FromContext(c.Request.Context(), name)
+}
+
+// FromContext reports whether the named feature is on in a request context
+func FromContext(ctx context.Context, name string) bool {
+	enabled, ok := ctx.Value(ctxKey{}).(map[string]bool)
+	return ok && enabled[name]
+}
@@ -0,0 +1,74 @@
+package features
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestFeatures runs a request through the middleware and reports which of
+// the asked-about feature names were enabled for it
+func requestFeatures(t *testing.T, defaults []string, header string, names ...string) map[string]bool {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	results := make(map[string]bool, len(names))
+	router := gin.New()
+	router.Use(Middleware(defaults))
+	router.GET("/", func(c *gin.Context) {
+		for _, name := range names {
+			results[name] = Enabled(c, name)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		request.Header.Set(Header, header)
+	}
+	router.ServeHTTP(httptest.NewRecorder(), request)
+	return results
+}
+
+func TestFeaturesOffByDefault(t *testing.T) {
+	results := requestFeatures(t, nil, "", StrictParams)
+	if results[StrictParams] {
+		t.Error("feature enabled without a header or default")
+	}
+}
+
+func TestFeaturesEnabledViaHeader(t *testing.T) {
+	results := requestFeatures(t, nil, " strict-params , lsp-completions ", StrictParams, LSPCompletions, "other")
+	if !results[StrictParams] || !results[LSPCompletions] {
+		t.Errorf("header features not enabled: %v", results)
+	}
+	if results["other"] {
+		t.Error("unlisted feature reported as enabled")
+	}
+}
+
+func TestFeaturesEnabledViaDefaults(t *testing.T) {
+	results := requestFeatures(t, []string{StrictParams}, "", StrictParams, LSPCompletions)
+	if !results[StrictParams] {
+		t.Error("configured default not enabled")
+	}
+	if results[LSPCompletions] {
+		t.Error("unconfigured feature enabled")
+	}
+}
+
+func TestFeaturesHeaderMergesOverDefaults(t *testing.T) {
+	results := requestFeatures(t, []string{StrictParams}, LSPCompletions, StrictParams, LSPCompletions)
+	if !results[StrictParams] || !results[LSPCompletions] {
+		t.Errorf("header did not merge over defaults: %v", results)
+	}
+}
+
+func TestFromContextWithoutMiddleware(t *testing.T) {
+	if FromContext(context.Background(), StrictParams) {
+		t.Error("feature enabled in a bare context")
+	}
+}
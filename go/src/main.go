@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -15,17 +16,19 @@ import (
 // Configuration holds the server configuration
 type Configuration struct {
 	Server struct {
-		Port        int      `json:"port"`
-		Host        string   `json:"host"`
-		MetricsPort int      `json:"metrics_port"`
-		LogLevel    string   `json:"log_level"`
-		Debug       bool     `json:"debug"`
-		CORS        CORSConfig `json:"cors"`
+		Port           int        `json:"port"`
+		Host           string     `json:"host"`
+		MetricsPort    int        `json:"metrics_port"`
+		LogLevel       string     `json:"log_level"`
+		Debug          bool       `json:"debug"`
+		TrustedProxies []string   `json:"trusted_proxies"`
+		CORS           CORSConfig `json:"cors"`
 	} `json:"server"`
 	MCP struct {
 		ProtocolVersion string `json:"protocol_version"`
 		ServerName      string `json:"server_name"`
 		ServerVersion   string `json:"server_version"`
+		SSEEnabled      bool   `json:"sse_enabled"`
 		Capabilities    struct {
 			SupportedLanguages []string `json:"supported_languages"`
 			SupportsNotebooks  bool     `json:"supports_notebooks"`
@@ -67,25 +70,27 @@ type HealthResponse struct {
 // loadConfig loads the configuration from environment variables
 func loadConfig() Configuration {
 	config := Configuration{}
-	
+
 	// Set defaults
 	config.Server.Port = 9090
 	config.Server.Host = "0.0.0.0"
 	config.Server.MetricsPort = 9091
 	config.Server.LogLevel = "info"
 	config.Server.Debug = false
-	
+	config.Server.TrustedProxies = strings.Split(getEnv("TRUSTED_PROXIES", "127.0.0.1,::1"), ",")
+
 	config.Server.CORS.AllowedOrigins = []string{"*"}
 	config.Server.CORS.AllowedMethods = []string{"GET", "POST", "OPTIONS"}
 	config.Server.CORS.AllowedHeaders = []string{"*"}
-	
+
 	config.MCP.ProtocolVersion = "2.0"
 	config.MCP.ServerName = getEnv("MCP_SERVER_NAME", "Go MCP Server")
 	config.MCP.ServerVersion = getEnv("MCP_SERVER_VERSION", "1.0.0")
+	config.MCP.SSEEnabled = getEnv("MCP_SSE_ENABLED", "true") != "false"
 	config.MCP.Capabilities.SupportedLanguages = []string{"go", "sql"}
 	config.MCP.Capabilities.SupportsNotebooks = true
 	config.MCP.Capabilities.SupportsStreaming = true
-	
+
 	return config
 }
 
@@ -98,23 +103,43 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// expandAllowedHeaders replaces a "*" entry with a concrete header set, since
+// browsers reject the wildcard when credentials are enabled
+func expandAllowedHeaders(headers []string) []string {
+	expanded := []string{}
+	for _, header := range headers {
+		if header == "*" {
+			expanded = append(expanded, "Accept", "Authorization", "Content-Type", "Origin", "X-API-Key", "X-Request-ID")
+			continue
+		}
+		expanded = append(expanded, header)
+	}
+	return expanded
+}
+
 // setupRouter sets up the Gin router
 func setupRouter(config Configuration) *gin.Engine {
 	if !config.Server.Debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
-	
+
 	router := gin.Default()
-	
+
+	// Only honor X-Forwarded-For from the configured proxies, so client IPs
+	// cannot be spoofed through untrusted hops
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
+
 	// Configure CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     config.Server.CORS.AllowedOrigins,
 		AllowMethods:     config.Server.CORS.AllowedMethods,
-		AllowHeaders:     config.Server.CORS.AllowedHeaders,
+		AllowHeaders:     expandAllowedHeaders(config.Server.CORS.AllowedHeaders),
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
-	
+
 	// Add health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, HealthResponse{
@@ -124,13 +149,19 @@ func setupRouter(config Configuration) *gin.Engine {
 			Service:   config.MCP.ServerName,
 		})
 	})
-	
+
 	// Add MCP endpoint
 	router.POST("/", handleMCPRequest(config))
-	
-	// Add SSE endpoint
-	router.GET("/sse", handleSSE(config))
-	
+
+	// Add SSE endpoint, unless streaming has been switched off
+	if config.MCP.SSEEnabled {
+		router.GET("/sse", handleSSE(config))
+	} else {
+		router.GET("/sse", func(c *gin.Context) {
+			c.Status(http.StatusNotImplemented)
+		})
+	}
+
 	return router
 }
 
@@ -150,10 +181,10 @@ func handleMCPRequest(config Configuration) gin.HandlerFunc {
 			})
 			return
 		}
-		
+
 		id, _ := request["id"]
 		method, _ := request["method"].(string)
-		
+
 		// Handle initialize method
 		if method == "initialize" {
 			c.JSON(http.StatusOK, MCPResponse{
@@ -216,8 +247,8 @@ func handleMCPRequest(config Configuration) gin.HandlerFunc {
 							},
 						},
 						"capabilities": map[string]interface{}{
-							"supportedLanguages":      config.MCP.Capabilities.SupportedLanguages,
-							"supportsNotebooks":       config.MCP.Capabilities.SupportsNotebooks,
+							"supportedLanguages":        config.MCP.Capabilities.SupportedLanguages,
+							"supportsNotebooks":         config.MCP.Capabilities.SupportsNotebooks,
 							"supportsInlineCompletions": true,
 						},
 					},
@@ -225,7 +256,7 @@ func handleMCPRequest(config Configuration) gin.HandlerFunc {
 			})
 			return
 		}
-		
+
 		// Forward to centralmind/gateway
 		// This is a placeholder - in a real implementation, you would forward the request to the gateway
 		c.JSON(http.StatusOK, MCPResponse{
@@ -239,22 +270,22 @@ func handleMCPRequest(config Configuration) gin.HandlerFunc {
 // handleSSE handles Server-Sent Events
 func handleSSE(config Configuration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Set headers for SSE
+		// Set headers for SSE. Transfer encoding is left to net/http, which
+		// negotiates chunking itself.
 		c.Writer.Header().Set("Content-Type", "text/event-stream")
 		c.Writer.Header().Set("Cache-Control", "no-cache")
 		c.Writer.Header().Set("Connection", "keep-alive")
-		c.Writer.Header().Set("Transfer-Encoding", "chunked")
-		
+
 		// Generate a client ID
 		clientID := fmt.Sprintf("%d", time.Now().UnixNano())
-		
+
 		// Send ready event
 		c.SSEvent("", map[string]interface{}{
 			"type":     "ready",
 			"clientId": clientID,
 		})
 		c.Writer.Flush()
-		
+
 		// Send capabilities
 		response := MCPResponse{
 			JSONRPC: "2.0",
@@ -316,27 +347,40 @@ func handleSSE(config Configuration) gin.HandlerFunc {
 						},
 					},
 					"capabilities": map[string]interface{}{
-						"supportedLanguages":      config.MCP.Capabilities.SupportedLanguages,
-						"supportsNotebooks":       config.MCP.Capabilities.SupportsNotebooks,
+						"supportedLanguages":        config.MCP.Capabilities.SupportedLanguages,
+						"supportsNotebooks":         config.MCP.Capabilities.SupportsNotebooks,
 						"supportsInlineCompletions": true,
 					},
 				},
 			},
 		}
-		
-		responseJSON, _ := json.Marshal(response)
-		c.SSEvent("", map[string]interface{}{
-			"data": string(responseJSON),
-		})
+
+		// A marshal failure must not push a garbled event at the client; send
+		// a structured error event instead
+		if responseJSON, err := json.Marshal(response); err != nil {
+			log.Printf("Failed to marshal SSE capabilities event: %v", err)
+			c.SSEvent("", map[string]interface{}{
+				"type":     "error",
+				"clientId": clientID,
+				"error": map[string]interface{}{
+					"code":    -32603,
+					"message": "Internal error",
+				},
+			})
+		} else {
+			c.SSEvent("", map[string]interface{}{
+				"data": string(responseJSON),
+			})
+		}
 		c.Writer.Flush()
-		
+
 		// Keep the connection alive with heartbeats
 		ticker := time.NewTicker(10 * time.Second)
 		defer ticker.Stop()
-		
+
 		// Use a channel to detect client disconnection
 		done := c.Request.Context().Done()
-		
+
 		for {
 			select {
 			case <-ticker.C:
@@ -355,11 +399,25 @@ func handleSSE(config Configuration) gin.HandlerFunc {
 	}
 }
 
+// logStartupSummary logs the effective configuration in one line so the
+// process setup is obvious from the logs
+func logStartupSummary(config Configuration) {
+	log.Printf("Effective configuration: port=%d metricsPort=%d languages=%v streaming=%t debug=%t",
+		config.Server.Port,
+		config.Server.MetricsPort,
+		config.MCP.Capabilities.SupportedLanguages,
+		config.MCP.Capabilities.SupportsStreaming,
+		config.Server.Debug,
+	)
+}
+
 func main() {
 	config := loadConfig()
-	
+
+	logStartupSummary(config)
+
 	router := setupRouter(config)
-	
+
 	// Start the server
 	addr := fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
 	log.Printf("Starting MCP server at %s", addr)
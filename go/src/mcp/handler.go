@@ -1,339 +1,1709 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/cploutarchou/mcp-servers/go/audit"
 	"github.com/cploutarchou/mcp-servers/go/codeassist"
 	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/cploutarchou/mcp-servers/go/database"
+	"github.com/cploutarchou/mcp-servers/go/features"
+	"github.com/cploutarchou/mcp-servers/go/respond"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-// Handler handles MCP protocol requests
+// Handler handles MCP protocol requests. The configuration and safe-mode
+// flag are read on every request and swapped by SIGHUP reloads, so they are
+// held atomically; everything else is wired once before the server starts.
 type Handler struct {
-	config *config.MCPConfig
-	logger *logrus.Logger
-	codeAssistHandler *codeassist.Handler
+	config              atomic.Pointer[config.MCPConfig]
+	logger              *logrus.Logger
+	codeAssistHandler   *codeassist.Handler
+	sessions            *SessionManager
+	auditWriter         *audit.Writer
+	upstreamClient      *http.Client
+	dbClient            *database.Client
+	sseIdleTimeout      time.Duration
+	sessionIdleTimeout  time.Duration
+	heartbeatInterval   time.Duration
+	methods             map[string]MethodHandler
+	sseDurationObserver func(seconds float64)
+	toolMetrics         ToolMetricsObserver
+	toolSemaphore       chan struct{}
+	idempotency         *idempotencyCache
+	sseDropObserver     func()
+	safeMode            atomic.Bool
+}
+
+// cfg returns the active MCP configuration. Reloads swap the pointer
+// atomically, so a reader sees either the old or the new configuration,
+// never a torn mix; callers needing several fields should capture the
+// snapshot once.
+func (h *Handler) cfg() *config.MCPConfig {
+	return h.config.Load()
+}
+
+// errSafeMode is the uniform error returned for paths disabled in safe mode
+var errSafeMode = errors.New("disabled in safe mode")
+
+// SetSafeMode disables the database tools and upstream forwarding, leaving
+// only the pure in-process code assistance paths. The advertised tool set
+// shrinks accordingly.
+func (h *Handler) SetSafeMode(enabled bool) {
+	h.safeMode.Store(enabled)
+}
+
+// AdvertisedToolNames lists the tools currently advertised to clients, sorted
+func (h *Handler) AdvertisedToolNames() []string {
+	tools := append(h.sqlTools(), h.codeAssistHandler.RegisterMCPTools()...)
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		if name, ok := tool["name"].(string); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// builtinMethods are the method names handled by the built-in dispatch switch
+var builtinMethods = []string{
+	"ping", "initialize", "notifications/initialized", "logging/setLevel",
+	"tools/call", "code_analysis_batch", "schema", "shutdown",
+}
+
+// RegisteredMethodNames lists every method the dispatcher accepts: the
+// built-in switch cases plus the registry entries, sorted
+func (h *Handler) RegisteredMethodNames() []string {
+	names := append([]string{}, builtinMethods...)
+	for name := range h.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DatabaseStatus reports whether a database is configured and reachable
+func (h *Handler) DatabaseStatus(ctx context.Context) map[string]interface{} {
+	if h.dbClient == nil {
+		return map[string]interface{}{"configured": false}
+	}
+
+	status := map[string]interface{}{
+		"configured": true,
+		"driver":     h.dbClient.Driver(),
+	}
+	if err := h.dbClient.Ping(ctx); err != nil {
+		status["reachable"] = false
+		status["error"] = err.Error()
+	} else {
+		status["reachable"] = true
+	}
+	return status
+}
+
+// CacheSizes reports the entry counts of the in-process caches
+func (h *Handler) CacheSizes() map[string]int {
+	return h.codeAssistHandler.CacheSizes()
+}
+
+// advertisedFingerprint summarizes the advertised tool set and supported
+// languages, so a config reload can detect whether clients need to be told
+func (h *Handler) advertisedFingerprint() string {
+	languages := append([]string{}, h.cfg().Capabilities.SupportedLanguages...)
+	sort.Strings(languages)
+
+	return strings.Join(h.AdvertisedToolNames(), ",") + "|" + strings.Join(languages, ",")
+}
+
+// ApplyConfig swaps in reloaded MCP configuration. When the reload changes
+// the advertised tool set or supported languages, connected clients are sent
+// a notifications/tools/list_changed so they re-query; an unchanged reload
+// emits nothing.
+func (h *Handler) ApplyConfig(cfg *config.MCPConfig, safeMode bool) {
+	before := h.advertisedFingerprint()
+	h.config.Store(cfg)
+	h.safeMode.Store(safeMode)
+	after := h.advertisedFingerprint()
+	if before == after {
+		return
+	}
+
+	notified := h.sessions.Broadcast(MCPNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/tools/list_changed",
+	})
+	h.logger.WithField("sessions", notified).Info("Advertised capabilities changed; notified connected clients")
+}
+
+// sseOutboundBufferSize bounds the per-connection outbound event buffer; a
+// consumer that falls this far behind is dropped rather than blocking the
+// delivery loop
+const sseOutboundBufferSize = 64
+
+// ToolMetricsObserver receives per-tool concurrency gauge updates from the
+// tool dispatch path (e.g. into Prometheus gauges)
+type ToolMetricsObserver interface {
+	ToolCallStarted(tool string)
+	ToolCallFinished(tool string)
+	ToolCallQueued(tool string)
+	ToolCallDequeued(tool string)
+}
+
+// SetToolMetricsObserver registers a receiver for per-tool concurrency gauges
+func (h *Handler) SetToolMetricsObserver(observer ToolMetricsObserver) {
+	h.toolMetrics = observer
+}
+
+// SetSSEDurationObserver registers a callback recording SSE session durations
+// (e.g. into a Prometheus histogram)
+func (h *Handler) SetSSEDurationObserver(observe func(seconds float64)) {
+	h.sseDurationObserver = observe
+}
+
+// SetSSEDropObserver registers a callback counting SSE connections dropped
+// because the client read too slowly
+func (h *Handler) SetSSEDropObserver(observe func()) {
+	h.sseDropObserver = observe
+}
+
+// MethodHandler handles a single named MCP method
+type MethodHandler func(c *gin.Context, request MCPRequest)
+
+// RegisterMethod registers a handler for an MCP method name. Registered
+// methods are consulted before the built-in dispatch, so they can also
+// override built-ins. Register before the server starts handling requests.
+func (h *Handler) RegisterMethod(name string, handler MethodHandler) {
+	h.methods[name] = handler
+}
+
+// SetDatabase configures the database client used by the SQL tools
+func (h *Handler) SetDatabase(client *database.Client) {
+	h.dbClient = client
+}
+
+// SetAuditWriter enables audit logging of tool invocations
+func (h *Handler) SetAuditWriter(writer *audit.Writer) {
+	h.auditWriter = writer
+}
+
+// SetSeverityOverrides sets per-rule severity overrides for code analysis
+func (h *Handler) SetSeverityOverrides(overrides map[string]string) {
+	h.codeAssistHandler.SetSeverityOverrides(overrides)
+}
+
+// SetDisabledRules disables the given analysis rule ids
+func (h *Handler) SetDisabledRules(ruleIDs []string) {
+	h.codeAssistHandler.SetDisabledRules(ruleIDs)
+}
+
+// SetMaxLineLength sets the limit used by the long-line analysis rule
+func (h *Handler) SetMaxLineLength(limit int) {
+	h.codeAssistHandler.SetMaxLineLength(limit)
+}
+
+// LoadDocumentationSources loads additional documentation files over the built-ins
+func (h *Handler) LoadDocumentationSources(paths []string) error {
+	return h.codeAssistHandler.LoadDocumentationSources(paths)
+}
+
+// SetDocCacheMaxEntries caps the number of cached documentation lookups
+func (h *Handler) SetDocCacheMaxEntries(maxEntries int) {
+	h.codeAssistHandler.SetDocCacheMaxEntries(maxEntries)
+}
+
+// SetCompletionTimeout sets the soft deadline for completion requests
+func (h *Handler) SetCompletionTimeout(timeout time.Duration) {
+	h.codeAssistHandler.SetCompletionTimeout(timeout)
+}
+
+// SetCompletionPopularity merges popularity overrides over the built-in
+// completion frequency table
+func (h *Handler) SetCompletionPopularity(overrides map[string]int) {
+	h.codeAssistHandler.SetCompletionPopularity(overrides)
+}
+
+// SetCacheMetricsObserver registers a receiver for cache hit/miss events
+func (h *Handler) SetCacheMetricsObserver(observer codeassist.CacheMetricsObserver) {
+	h.codeAssistHandler.SetCacheMetricsObserver(observer)
+}
+
+// SetAnalysisMetricsObserver registers a receiver for per-severity
+// diagnostic counts from analysis runs
+func (h *Handler) SetAnalysisMetricsObserver(observer codeassist.AnalysisMetricsObserver) {
+	h.codeAssistHandler.SetAnalysisMetricsObserver(observer)
+}
+
+// LoadCompletionKeywords loads extra per-language completion keywords from a file
+func (h *Handler) LoadCompletionKeywords(path string) error {
+	return h.codeAssistHandler.LoadCompletionKeywords(path)
+}
+
+// Warmup primes the code-assist paths so the first request is not cold
+func (h *Handler) Warmup() error {
+	return h.codeAssistHandler.Warmup()
 }
 
 // NewHandler creates a new MCP handler
 func NewHandler(config *config.MCPConfig, logger *logrus.Logger) *Handler {
-	return &Handler{
-		config: config,
-		logger: logger,
-		codeAssistHandler: codeassist.NewHandler(logger),
+	upstreamTimeout := 30 * time.Second
+	if config.UpstreamTimeout != "" {
+		if timeout, err := time.ParseDuration(config.UpstreamTimeout); err == nil {
+			upstreamTimeout = timeout
+		}
+	}
+
+	// Zero means the idle reaper is disabled (validated at config load)
+	sseIdleTimeout, _ := time.ParseDuration(config.SSEIdleTimeout)
+
+	// Session state is always evicted eventually, even when the SSE idle
+	// reaper is off; session ids are client-chosen, so an unbounded map is an
+	// open invitation to inflate it
+	sessionIdleTimeout := sseIdleTimeout
+	if sessionIdleTimeout <= 0 {
+		sessionIdleTimeout = defaultSessionIdleTimeout
+	}
+
+	handler := &Handler{
+		logger:             logger,
+		codeAssistHandler:  codeassist.NewHandler(logger),
+		sessions:           NewSessionManager(),
+		upstreamClient:     &http.Client{Timeout: upstreamTimeout},
+		sseIdleTimeout:     sseIdleTimeout,
+		sessionIdleTimeout: sessionIdleTimeout,
+		heartbeatInterval:  defaultHeartbeatInterval,
+		methods:            make(map[string]MethodHandler),
+	}
+	handler.config.Store(config)
+
+	handler.codeAssistHandler.SetMaxCompletionBytes(config.MaxCompletionBytes)
+	handler.codeAssistHandler.SetStrictParams(config.StrictParams)
+
+	// Zero leaves tool-call concurrency unbounded
+	if config.MaxConcurrentToolCalls > 0 {
+		handler.toolSemaphore = make(chan struct{}, config.MaxConcurrentToolCalls)
+	}
+
+	// Idempotent replay of tool calls is off unless configured (validated at
+	// config load)
+	if config.Idempotency.Enabled {
+		ttl, _ := time.ParseDuration(config.Idempotency.TTL)
+		handler.idempotency = newIdempotencyCache(ttl, config.Idempotency.MaxEntries)
+	}
+
+	// The code-assist methods are ordinary registry entries; custom methods
+	// registered by embedders follow the same path
+	for _, method := range []string{"code_completion", "code_analysis", "code_documentation", "sql_format"} {
+		handler.RegisterMethod(method, handler.gatedCodeAssistMethod())
+	}
+
+	go handler.reapIdleSessions()
+
+	return handler
+}
+
+// defaultSessionIdleTimeout bounds session lifetime when no SSE idle timeout
+// is configured
+const defaultSessionIdleTimeout = 30 * time.Minute
+
+// defaultHeartbeatInterval paces SSE heartbeats and the idle-reap check
+const defaultHeartbeatInterval = 10 * time.Second
+
+// sessionReapInterval is how often idle sessions are swept
+const sessionReapInterval = time.Minute
+
+// reapIdleSessions periodically evicts sessions idle beyond the timeout, so
+// abandoned or fabricated session ids do not accumulate for the process
+// lifetime
+func (h *Handler) reapIdleSessions() {
+	ticker := time.NewTicker(sessionReapInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if evicted := h.sessions.EvictIdle(h.sessionIdleTimeout); evicted > 0 {
+			h.logger.WithField("sessions", evicted).Debug("Evicted idle sessions")
+		}
+	}
+}
+
+// gatedCodeAssistMethod wraps handleCodeAssistRequest with the initialization
+// gate applied to all tool-like methods
+func (h *Handler) gatedCodeAssistMethod() MethodHandler {
+	return func(c *gin.Context, request MCPRequest) {
+		if !h.sessions.IsInitialized(h.sessionID(c)) {
+			h.logger.WithField("method", request.Method).Warn("Tool call received before initialization")
+			respond.JSON(c, http.StatusOK, MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32002,
+					Message: "server not initialized",
+					Data:    map[string]string{"method": request.Method},
+				},
+			})
+			return
+		}
+		h.handleCodeAssistRequest(c, request)
+	}
+}
+
+// sessionID resolves the session identifier for a request, falling back to the client IP
+func (h *Handler) sessionID(c *gin.Context) string {
+	if id := c.GetHeader("Mcp-Session-Id"); id != "" {
+		return id
+	}
+	// EventSource clients cannot set headers, so accept the session id as a
+	// query parameter on reconnects
+	if id := c.Query("sessionId"); id != "" {
+		return id
+	}
+	return c.ClientIP()
+}
+
+// newSessionID mints a random session identifier for clients that did not
+// present one
+func newSessionID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// MCPRequest represents an MCP protocol request
+type MCPRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      interface{}     `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	// Meta carries out-of-band data such as progress tokens and trace ids
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// MCPResponse represents an MCP protocol response
+type MCPResponse struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      interface{} `json:"id"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *MCPError   `json:"error,omitempty"`
+	// Meta echoes the client's correlation fields back on the response
+	Meta map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// metaEchoFields are the _meta keys echoed back to clients; everything else
+// in _meta is treated as request-scoped and dropped
+var metaEchoFields = []string{"progressToken", "requestId", "traceId", "traceparent"}
+
+// requestMeta returns the request's _meta object, preferring the envelope
+// field and falling back to a _meta member inside params
+func requestMeta(request MCPRequest) map[string]interface{} {
+	if len(request.Meta) > 0 {
+		return request.Meta
+	}
+
+	var params struct {
+		Meta map[string]interface{} `json:"_meta"`
+	}
+	if len(request.Params) > 0 && json.Unmarshal(request.Params, &params) == nil {
+		return params.Meta
+	}
+	return nil
+}
+
+// echoMeta copies the client's correlation fields from the request _meta
+// onto the response, so progress tokens and trace ids survive dispatch
+func echoMeta(request MCPRequest, response *MCPResponse) {
+	meta := requestMeta(request)
+	if len(meta) == 0 {
+		return
+	}
+
+	echoed := map[string]interface{}{}
+	for _, key := range metaEchoFields {
+		if value, ok := meta[key]; ok {
+			echoed[key] = value
+		}
+	}
+	if len(echoed) > 0 {
+		response.Meta = echoed
+	}
+}
+
+// MCPError represents an error in the MCP protocol
+type MCPError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// jsonKind names the top-level JSON kind of a payload from its first
+// non-whitespace byte
+func jsonKind(body []byte) string {
+	for _, b := range body {
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '[':
+			return "array"
+		case '{':
+			return "object"
+		case '"':
+			return "string"
+		case 't', 'f':
+			return "boolean"
+		case 'n':
+			return "null"
+		default:
+			return "number"
+		}
+	}
+	return "empty"
+}
+
+// duplicateBatchIDs returns the non-null request ids appearing more than once
+// in a batch payload. Notifications, which carry no id, are exempt. Ids are
+// compared as raw JSON tokens so the string "1" and the number 1 stay
+// distinct instead of colliding on their printed form.
+func duplicateBatchIDs(body []byte) []string {
+	var entries []struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil
+	}
+
+	seen := make(map[string]int, len(entries))
+	duplicates := []string{}
+	for _, entry := range entries {
+		key := string(entry.ID)
+		if key == "" || key == "null" {
+			continue
+		}
+		seen[key]++
+		if seen[key] == 2 {
+			duplicates = append(duplicates, key)
+		}
+	}
+
+	return duplicates
+}
+
+// validRequestID reports whether a decoded JSON-RPC id is a scalar: string,
+// number, or null
+func validRequestID(id interface{}) bool {
+	switch id.(type) {
+	case nil, string, float64, json.Number:
+		return true
+	default:
+		return false
+	}
+}
+
+// HandleMCPRequest handles MCP protocol requests
+func (h *Handler) HandleMCPRequest(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to read MCP request body")
+		respond.JSON(c, http.StatusBadRequest, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error: &MCPError{
+				Code:    -32700,
+				Message: "Parse error",
+				Data:    map[string]string{"detail": err.Error()},
+			},
+		})
+		return
+	}
+
+	var request MCPRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		kind := jsonKind(body)
+		detail := err.Error()
+
+		// A top-level array is usually an accidental batch; tell the
+		// client that plainly instead of leaking the unmarshal error
+		if kind == "array" {
+			// Duplicate ids break response correlation and are invalid
+			// regardless of batch support, so call them out specifically
+			if duplicates := duplicateBatchIDs(body); len(duplicates) > 0 {
+				respond.JSON(c, http.StatusBadRequest, MCPResponse{
+					JSONRPC: "2.0",
+					ID:      nil,
+					Error: &MCPError{
+						Code:    -32600,
+						Message: "Invalid Request",
+						Data: map[string]interface{}{
+							"detail":       "duplicate request ids within batch",
+							"duplicateIds": duplicates,
+						},
+					},
+				})
+				return
+			}
+			detail = "batch requests are not supported; send a single request object"
+		}
+
+		h.logger.WithError(err).WithField("kind", kind).Error("Failed to parse MCP request")
+		respond.JSON(c, http.StatusBadRequest, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error: &MCPError{
+				Code:    -32700,
+				Message: "Parse error",
+				Data:    map[string]string{"detail": detail, "kind": kind},
+			},
+		})
+		return
+	}
+
+	// JSON-RPC ids must be a string, number, or null; a composite id cannot
+	// be correlated with its response, so reject it before dispatch. Numeric
+	// ids stay numbers (float64 from the decoder) and echo back unstringified.
+	if !validRequestID(request.ID) {
+		h.logger.WithField("id", request.ID).Warn("Rejecting MCP request with non-scalar id")
+		respond.JSON(c, http.StatusBadRequest, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      nil,
+			Error: &MCPError{
+				Code:    -32600,
+				Message: "Invalid Request",
+				Data:    map[string]string{"detail": "id must be a string, number, or null"},
+			},
+		})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"method": request.Method,
+		"id":     request.ID,
+	}).Info("Received MCP request")
+
+	// Any request counts as activity for the session's idle clock
+	h.sessions.Touch(h.sessionID(c))
+
+	// Registered methods take precedence over the built-in dispatch
+	if handler, ok := h.methods[request.Method]; ok {
+		handler(c, request)
+		return
+	}
+
+	// Handle different methods
+	switch request.Method {
+	case "ping":
+		h.handlePing(c, request)
+	case "initialize":
+		h.handleInitialize(c, request)
+	case "notifications/initialized":
+		h.handleInitializedNotification(c, request)
+	case "logging/setLevel":
+		h.handleSetLogLevel(c, request)
+	case "tools/call":
+		h.handleToolCall(c, request)
+	case "code_analysis_batch":
+		h.handleBatchAnalysis(c, request)
+	case "schema":
+		h.handleSchema(c, request)
+	case "shutdown":
+		h.handleShutdown(c, request)
+	default:
+		h.handleUnknownMethod(c, request)
+	}
+}
+
+// handlePing answers a liveness probe over the JSON-RPC channel. It
+// deliberately bypasses initialization gating so clients can verify the
+// transport before (or without) completing the handshake.
+func (h *Handler) handlePing(c *gin.Context, request MCPRequest) {
+	respond.JSON(c, http.StatusOK, MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"pong":       true,
+			"serverTime": time.Now().UTC().Format(time.RFC3339Nano),
+		},
+	})
+}
+
+// handleInitializedNotification handles the initialized notification sent after initialize
+func (h *Handler) handleInitializedNotification(c *gin.Context, request MCPRequest) {
+	sessionID := h.sessionID(c)
+	h.sessions.MarkInitialized(sessionID)
+	h.logger.WithField("session", sessionID).Info("Session initialized")
+
+	// Notifications carry no id and expect no response body
+	c.Status(http.StatusNoContent)
+}
+
+// supportedProtocolVersions lists the protocol versions this server can
+// speak, preferred first
+var supportedProtocolVersions = []string{"2.0", "1.0"}
+
+// negotiateProtocolVersion picks the protocol version for a session. A client
+// sending no version keeps the configured default; a supported version is
+// echoed back; anything else is rejected.
+func (h *Handler) negotiateProtocolVersion(params json.RawMessage) (string, error) {
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	_ = json.Unmarshal(params, &init)
+
+	if init.ProtocolVersion == "" {
+		return h.cfg().ProtocolVersion, nil
+	}
+	for _, version := range supportedProtocolVersions {
+		if version == init.ProtocolVersion {
+			return version, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported protocol version: %s", init.ProtocolVersion)
+}
+
+// handleInitialize handles the initialize method
+func (h *Handler) handleInitialize(c *gin.Context, request MCPRequest) {
+	h.logger.Info("Handling initialize request")
+
+	protocolVersion, err := h.negotiateProtocolVersion(request.Params)
+	if err != nil {
+		respond.JSON(c, http.StatusBadRequest, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: err.Error(),
+				Data:    map[string]interface{}{"supported": supportedProtocolVersions},
+			},
+		})
+		return
+	}
+
+	// Reuse the session id the client presented (a reconnect rejoins its
+	// previous session state); mint a fresh one otherwise. Echoing it in the
+	// Mcp-Session-Id header and the result lets the client carry it on
+	// subsequent requests and SSE reconnects.
+	sessionID := c.GetHeader("Mcp-Session-Id")
+	if sessionID == "" {
+		sessionID = c.Query("sessionId")
+	}
+	if sessionID == "" {
+		sessionID = newSessionID()
+	}
+
+	// Create the session; it becomes usable once the initialized notification arrives
+	h.sessions.GetOrCreate(sessionID)
+	c.Header("Mcp-Session-Id", sessionID)
+
+	cfg := h.cfg()
+	response := MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result: map[string]interface{}{
+			"sessionId":       sessionID,
+			"protocolVersion": protocolVersion,
+			"capabilities": map[string]interface{}{
+				"serverName":    cfg.ServerName,
+				"serverVersion": cfg.ServerVersion,
+				"tools":         h.sqlTools(),
+				"capabilities": map[string]interface{}{
+					"supportedLanguages":        cfg.Capabilities.SupportedLanguages,
+					"supportsNotebooks":         cfg.Capabilities.SupportsNotebooks,
+					"supportsInlineCompletions": true,
+					"supportsStreaming":         cfg.Capabilities.SupportsStreaming && cfg.SSEEnabled,
+				},
+			},
+		},
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// sqlTools returns the SQL tool definitions advertised to clients. The
+// parameters maps double as the tools' JSON Schemas. In safe mode the
+// database tools are unavailable and therefore not advertised.
+func (h *Handler) sqlTools() []map[string]interface{} {
+	if h.safeMode.Load() {
+		return []map[string]interface{}{}
+	}
+	return []map[string]interface{}{
+		{
+			"name":        "list_tables",
+			"description": "List all available tables",
+			"parameters": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "discover_data",
+			"description": "Discover data in tables",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"table": map[string]interface{}{
+						"type":        "string",
+						"description": "Table name to discover",
+					},
+				},
+				"required": []string{"table"},
+			},
+		},
+		{
+			"name":        "prepare_query",
+			"description": "Prepare a SQL query",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL query to prepare",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			"name":        "query",
+			"description": "Execute a SQL query",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "SQL query to execute",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+// toolSchemas builds a JSON Schema document for every advertised tool,
+// combining the SQL tools with the code assistance tools
+func (h *Handler) toolSchemas() []map[string]interface{} {
+	tools := append(h.sqlTools(), h.codeAssistHandler.RegisterMCPTools()...)
+
+	schemas := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		schema := map[string]interface{}{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+		}
+		if parameters, ok := tool["parameters"].(map[string]interface{}); ok {
+			for key, value := range parameters {
+				schema[key] = value
+			}
+		}
+		schemas = append(schemas, map[string]interface{}{
+			"name":        tool["name"],
+			"description": tool["description"],
+			"schema":      schema,
+		})
+	}
+
+	return schemas
+}
+
+// HandleSchema serves the JSON Schemas of all tool parameters over HTTP
+func (h *Handler) HandleSchema(c *gin.Context) {
+	respond.JSON(c, http.StatusOK, gin.H{"tools": h.toolSchemas()})
+}
+
+// handleSchema handles the schema method over MCP
+func (h *Handler) handleSchema(c *gin.Context, request MCPRequest) {
+	respond.JSON(c, http.StatusOK, MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  map[string]interface{}{"tools": h.toolSchemas()},
+	})
+}
+
+// MCPNotification represents a server-to-client JSON-RPC notification
+type MCPNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// handleSetLogLevel handles the logging/setLevel method
+func (h *Handler) handleSetLogLevel(c *gin.Context, request MCPRequest) {
+	var params struct {
+		Level string `json:"level"`
+	}
+	if len(request.Params) > 0 {
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			respond.JSON(c, http.StatusOK, MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: "Invalid params",
+					Data:    map[string]string{"detail": err.Error()},
+				},
+			})
+			return
+		}
+	}
+
+	if !IsValidLogLevel(params.Level) {
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    map[string]string{"detail": fmt.Sprintf("unknown log level: %s", params.Level)},
+			},
+		})
+		return
+	}
+
+	sessionID := h.sessionID(c)
+	h.sessions.SetLogLevel(sessionID, params.Level)
+	h.logger.WithFields(logrus.Fields{
+		"session": sessionID,
+		"level":   params.Level,
+	}).Info("Session log level updated")
+
+	respond.JSON(c, http.StatusOK, MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  map[string]interface{}{},
+	})
+}
+
+// EmitLogMessage pushes a notifications/message event to the session if it passes
+// the session's configured verbosity
+func (h *Handler) EmitLogMessage(sessionID, level, loggerName string, data interface{}) {
+	if !h.sessions.ShouldLog(sessionID, level) {
+		return
+	}
+
+	notification := MCPNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params: map[string]interface{}{
+			"level":  level,
+			"logger": loggerName,
+			"data":   data,
+		},
+	}
+
+	if !h.sessions.Push(sessionID, notification) {
+		h.logger.WithField("session", sessionID).Warn("Dropped log notification: session event buffer full")
+	}
+}
+
+// handleShutdown handles the shutdown method
+func (h *Handler) handleShutdown(c *gin.Context, request MCPRequest) {
+	h.logger.Info("Handling shutdown request")
+
+	response := MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  map[string]interface{}{"success": true},
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// handleUnknownMethod handles unknown methods
+func (h *Handler) handleUnknownMethod(c *gin.Context, request MCPRequest) {
+	// Forward unhandled methods to the upstream gateway when configured;
+	// safe mode keeps everything in-process
+	if h.cfg().UpstreamURL != "" {
+		if h.safeMode.Load() {
+			respond.JSON(c, http.StatusOK, MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32603,
+					Message: errSafeMode.Error(),
+					Data:    map[string]string{"method": request.Method},
+				},
+			})
+			return
+		}
+		h.forwardToUpstream(c, request)
+		return
+	}
+
+	h.logger.WithField("method", request.Method).Warn("Unknown method requested")
+
+	response := MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Error: &MCPError{
+			Code:    -32601,
+			Message: "Method not found",
+			Data:    map[string]string{"method": request.Method},
+		},
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// forwardToUpstream proxies an unhandled JSON-RPC request to the configured
+// upstream gateway and streams the response back, preserving the request id
+func (h *Handler) forwardToUpstream(c *gin.Context, request MCPRequest) {
+	upstreamURL := h.cfg().UpstreamURL
+	h.logger.WithFields(logrus.Fields{
+		"method":   request.Method,
+		"upstream": upstreamURL,
+	}).Info("Forwarding request to upstream gateway")
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		h.upstreamError(c, request, err)
+		return
+	}
+
+	upstreamRequest, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, upstreamURL, bytes.NewReader(body))
+	if err != nil {
+		h.upstreamError(c, request, err)
+		return
+	}
+	upstreamRequest.Header.Set("Content-Type", "application/json")
+
+	upstreamResponse, err := h.upstreamClient.Do(upstreamRequest)
+	if err != nil {
+		h.upstreamError(c, request, err)
+		return
+	}
+	defer upstreamResponse.Body.Close()
+
+	contentType := upstreamResponse.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	c.DataFromReader(upstreamResponse.StatusCode, upstreamResponse.ContentLength, contentType, upstreamResponse.Body, nil)
+}
+
+// upstreamError reports a failure to reach the upstream gateway
+func (h *Handler) upstreamError(c *gin.Context, request MCPRequest, err error) {
+	h.logger.WithError(err).Error("Failed to forward request to upstream gateway")
+	respond.JSON(c, http.StatusOK, MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Error: &MCPError{
+			Code:    -32603,
+			Message: "Upstream gateway error",
+			Data:    map[string]string{"detail": err.Error()},
+		},
+	})
+}
+
+// strictParamsEnabled reports whether strict parameter decoding applies to
+// the request: either configured globally or enabled per request via the
+// strict-params feature flag
+func (h *Handler) strictParamsEnabled(ctx context.Context) bool {
+	return h.cfg().StrictParams || features.FromContext(ctx, features.StrictParams)
+}
+
+// staleCompletionSeq reports whether a completion request carries a seq older
+// than the latest seen for the session, recording the seq otherwise. Requests
+// without a seq are never stale, so clients that do not send one are
+// unaffected.
+func (h *Handler) staleCompletionSeq(sessionID string, params json.RawMessage) bool {
+	var probe struct {
+		Seq int64 `json:"seq"`
+	}
+	if len(params) == 0 || json.Unmarshal(params, &probe) != nil || probe.Seq == 0 {
+		return false
+	}
+	if h.sessions.AdvanceCompletionSeq(sessionID, probe.Seq) {
+		return false
+	}
+	h.logger.WithFields(logrus.Fields{
+		"session": sessionID,
+		"seq":     probe.Seq,
+	}).Info("Dropping stale completion request")
+	return true
+}
+
+// handleCodeAssistRequest handles code assistance requests
+func (h *Handler) handleCodeAssistRequest(c *gin.Context, request MCPRequest) {
+	h.logger.WithField("method", request.Method).Info("Handling code assistance request")
+
+	// An omitted params member is valid JSON-RPC, but every code-assist
+	// method requires arguments; report that precisely instead of letting
+	// json.Unmarshal fail with an opaque internal error
+	if len(request.Params) == 0 {
+		h.logger.WithField("method", request.Method).Warn("Code assistance request missing params")
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: fmt.Sprintf("params required for method %s", request.Method),
+				Data:    map[string]string{"method": request.Method},
+			},
+		})
+		return
+	}
+
+	// Drop completion requests the client has already typed past; the empty
+	// incomplete result tells the client to re-request when it settles
+	if request.Method == "code_completion" && h.staleCompletionSeq(h.sessionID(c), request.Params) {
+		response := MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Result:  &codeassist.CompletionResponse{Items: []codeassist.CompletionItem{}, Incomplete: true},
+		}
+		echoMeta(request, &response)
+		respond.JSON(c, http.StatusOK, response)
+		return
+	}
+
+	h.EmitLogMessage(h.sessionID(c), "info", "mcp", fmt.Sprintf("processing %s request", request.Method))
+
+	result, err := h.codeAssistHandler.HandleMCPRequest(request.Method, request.Params)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to handle code assistance request")
+		// An oversized code payload is the caller's mistake, not ours
+		if errors.Is(err, codeassist.ErrCodeTooLarge) {
+			respond.JSON(c, http.StatusOK, MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: "code too large",
+					Data:    map[string]string{"detail": err.Error()},
+				},
+			})
+			return
+		}
+		// A typo'd parameter name caught by strict decoding is likewise the
+		// caller's mistake
+		if errors.Is(err, codeassist.ErrUnknownParam) {
+			respond.JSON(c, http.StatusOK, MCPResponse{
+				JSONRPC: "2.0",
+				ID:      request.ID,
+				Error: &MCPError{
+					Code:    -32602,
+					Message: "Invalid params",
+					Data:    map[string]string{"detail": err.Error()},
+				},
+			})
+			return
+		}
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    map[string]string{"detail": err.Error()},
+			},
+		})
+		return
 	}
-}
-
-// MCPRequest represents an MCP protocol request
-type MCPRequest struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      interface{}     `json:"id"`
-	Method  string          `json:"method"`
-	Params  json.RawMessage `json:"params,omitempty"`
-}
 
-// MCPResponse represents an MCP protocol response
-type MCPResponse struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      interface{} `json:"id"`
-	Result  interface{} `json:"result,omitempty"`
-	Error   *MCPError   `json:"error,omitempty"`
+	response := MCPResponse{
+		JSONRPC: "2.0",
+		ID:      request.ID,
+		Result:  result,
+	}
+	echoMeta(request, &response)
+	respond.JSON(c, http.StatusOK, response)
 }
 
-// MCPError represents an error in the MCP protocol
-type MCPError struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-}
+// handleBatchAnalysis handles batch code analysis over MCP, emitting progress
+// notifications keyed by the request's _meta progress token
+func (h *Handler) handleBatchAnalysis(c *gin.Context, request MCPRequest) {
+	sessionID := h.sessionID(c)
 
-// HandleMCPRequest handles MCP protocol requests
-func (h *Handler) HandleMCPRequest(c *gin.Context) {
-	var request MCPRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		h.logger.WithError(err).Error("Failed to parse MCP request")
-		c.JSON(http.StatusBadRequest, MCPResponse{
+	var batchRequest codeassist.BatchAnalysisRequest
+	if err := codeassist.UnmarshalParams(request.Params, &batchRequest, h.strictParamsEnabled(c.Request.Context())); err != nil {
+		respond.JSON(c, http.StatusOK, MCPResponse{
 			JSONRPC: "2.0",
-			ID:      nil,
+			ID:      request.ID,
 			Error: &MCPError{
-				Code:    -32700,
-				Message: "Parse error",
+				Code:    -32602,
+				Message: "Invalid params",
 				Data:    map[string]string{"detail": err.Error()},
 			},
 		})
 		return
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"method": request.Method,
-		"id":     request.ID,
-	}).Info("Received MCP request")
+	// Pull the progress token out of the request _meta, if any
+	var meta struct {
+		Meta struct {
+			ProgressToken interface{} `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	_ = json.Unmarshal(request.Params, &meta)
 
-	// Handle different methods
-	switch request.Method {
-	case "initialize":
-		h.handleInitialize(c, request)
-	case "shutdown":
-		h.handleShutdown(c, request)
-	default:
-		h.handleUnknownMethod(c, request)
+	var progress codeassist.ProgressFunc
+	if meta.Meta.ProgressToken != nil {
+		progressToken := meta.Meta.ProgressToken
+		progress = func(completed, total int) {
+			h.sessions.Push(sessionID, MCPNotification{
+				JSONRPC: "2.0",
+				Method:  "notifications/progress",
+				Params: map[string]interface{}{
+					"progressToken": progressToken,
+					"progress":      completed,
+					"total":         total,
+				},
+			})
+		}
 	}
-}
 
-// handleInitialize handles the initialize method
-func (h *Handler) handleInitialize(c *gin.Context, request MCPRequest) {
-	h.logger.Info("Handling initialize request")
+	result, err := h.codeAssistHandler.AnalyzeBatch(&batchRequest, progress)
+	if err != nil {
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32603,
+				Message: "Internal error",
+				Data:    map[string]string{"detail": err.Error()},
+			},
+		})
+		return
+	}
 
 	response := MCPResponse{
 		JSONRPC: "2.0",
 		ID:      request.ID,
-		Result: map[string]interface{}{
-			"capabilities": map[string]interface{}{
-				"serverName":    h.config.ServerName,
-				"serverVersion": h.config.ServerVersion,
-				"tools": []map[string]interface{}{
-					{
-						"name":        "list_tables",
-						"description": "List all available tables",
-						"parameters": map[string]interface{}{
-							"type":       "object",
-							"properties": map[string]interface{}{},
-						},
-					},
-					{
-						"name":        "discover_data",
-						"description": "Discover data in tables",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"table": map[string]interface{}{
-									"type":        "string",
-									"description": "Table name to discover",
-								},
-							},
-							"required": []string{"table"},
-						},
-					},
-					{
-						"name":        "prepare_query",
-						"description": "Prepare a SQL query",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"query": map[string]interface{}{
-									"type":        "string",
-									"description": "SQL query to prepare",
-								},
-							},
-							"required": []string{"query"},
-						},
-					},
-					{
-						"name":        "query",
-						"description": "Execute a SQL query",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"query": map[string]interface{}{
-									"type":        "string",
-									"description": "SQL query to execute",
-								},
-							},
-							"required": []string{"query"},
-						},
-					},
-				},
-				"capabilities": map[string]interface{}{
-					"supportedLanguages":       h.config.Capabilities.SupportedLanguages,
-					"supportsNotebooks":        h.config.Capabilities.SupportsNotebooks,
-					"supportsInlineCompletions": true,
-				},
-			},
-		},
+		Result:  result,
 	}
+	echoMeta(request, &response)
+	respond.JSON(c, http.StatusOK, response)
+}
 
-	c.JSON(http.StatusOK, response)
+// ToolCallParams represents the params of a tools/call request
+type ToolCallParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
 }
 
-// handleShutdown handles the shutdown method
-func (h *Handler) handleShutdown(c *gin.Context, request MCPRequest) {
-	h.logger.Info("Handling shutdown request")
+// idempotencyKey extracts the idempotency key from the Idempotency-Key header
+// or the params' _meta.idempotencyKey field
+func idempotencyKey(c *gin.Context, params json.RawMessage) string {
+	if key := c.GetHeader("Idempotency-Key"); key != "" {
+		return key
+	}
 
-	response := MCPResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result:  map[string]interface{}{"success": true},
+	var meta struct {
+		Meta struct {
+			IdempotencyKey string `json:"idempotencyKey"`
+		} `json:"_meta"`
+	}
+	if len(params) > 0 && json.Unmarshal(params, &meta) == nil {
+		return meta.Meta.IdempotencyKey
 	}
 
-	c.JSON(http.StatusOK, response)
+	return ""
 }
 
-// handleUnknownMethod handles unknown methods
-func (h *Handler) handleUnknownMethod(c *gin.Context, request MCPRequest) {
-	h.logger.WithField("method", request.Method).Info("Checking if method is a code assistance method")
+// handleToolCall handles the tools/call method
+func (h *Handler) handleToolCall(c *gin.Context, request MCPRequest) {
+	sessionID := h.sessionID(c)
 
-	// Check if the method is a code assistance method
-	if request.Method == "code_completion" || request.Method == "code_analysis" || request.Method == "code_documentation" {
-		h.handleCodeAssistRequest(c, request)
+	if !h.sessions.IsInitialized(sessionID) {
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32002,
+				Message: "server not initialized",
+			},
+		})
 		return
 	}
 
-	h.logger.WithField("method", request.Method).Warn("Unknown method requested")
+	var params ToolCallParams
+	if err := codeassist.UnmarshalParams(request.Params, &params, h.strictParamsEnabled(c.Request.Context())); err != nil {
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    map[string]string{"detail": err.Error()},
+			},
+		})
+		return
+	}
+	if params.Name == "" {
+		respond.JSON(c, http.StatusOK, MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &MCPError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    map[string]string{"detail": "tools/call requires a tool name"},
+			},
+		})
+		return
+	}
 
-	response := MCPResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Error: &MCPError{
-			Code:    -32601,
-			Message: "Method not found",
-			Data:    map[string]string{"method": request.Method},
-		},
+	// The completion debounce applies to the tool form of the method too
+	if params.Name == "code_completion" && h.staleCompletionSeq(sessionID, params.Arguments) {
+		response := MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Result:  &codeassist.CompletionResponse{Items: []codeassist.CompletionItem{}, Incomplete: true},
+		}
+		echoMeta(request, &response)
+		respond.JSON(c, http.StatusOK, response)
+		return
 	}
 
-	c.JSON(http.StatusOK, response)
-}
+	h.logger.WithFields(logrus.Fields{
+		"tool":    params.Name,
+		"session": sessionID,
+	}).Info("Handling tool call")
 
-// handleCodeAssistRequest handles code assistance requests
-func (h *Handler) handleCodeAssistRequest(c *gin.Context, request MCPRequest) {
-	h.logger.WithField("method", request.Method).Info("Handling code assistance request")
+	// Replay the cached response for retried requests carrying an
+	// idempotency key, instead of re-executing the tool. The cache key is
+	// scoped to the tool so keys cannot collide across tools.
+	cacheKey := ""
+	if h.idempotency != nil {
+		if key := idempotencyKey(c, request.Params); key != "" {
+			cacheKey = params.Name + "\x00" + key
+			if cached, ok := h.idempotency.get(cacheKey); ok {
+				h.logger.WithFields(logrus.Fields{
+					"tool":    params.Name,
+					"session": sessionID,
+				}).Info("Replaying idempotent tool call")
+				cached.ID = request.ID
+				respond.JSON(c, http.StatusOK, cached)
+				return
+			}
+		}
+	}
 
-	result, err := h.codeAssistHandler.HandleMCPRequest(request.Method, request.Params)
+	// Wait for a concurrency slot when a limit is configured, reporting the
+	// time spent queued as queue depth. The wait gives up when the client
+	// disconnects, so abandoned requests do not pin goroutines in the queue.
+	if h.toolSemaphore != nil {
+		select {
+		case h.toolSemaphore <- struct{}{}:
+		default:
+			if h.toolMetrics != nil {
+				h.toolMetrics.ToolCallQueued(params.Name)
+			}
+			select {
+			case h.toolSemaphore <- struct{}{}:
+			case <-c.Request.Context().Done():
+				if h.toolMetrics != nil {
+					h.toolMetrics.ToolCallDequeued(params.Name)
+				}
+				h.logger.WithFields(logrus.Fields{
+					"tool":    params.Name,
+					"session": sessionID,
+				}).Info("Tool call abandoned while queued: client disconnected")
+				respond.JSON(c, http.StatusOK, MCPResponse{
+					JSONRPC: "2.0",
+					ID:      request.ID,
+					Error: &MCPError{
+						Code:    -32603,
+						Message: "Internal error",
+						Data:    map[string]string{"detail": "request cancelled while queued for a tool slot"},
+					},
+				})
+				return
+			}
+			if h.toolMetrics != nil {
+				h.toolMetrics.ToolCallDequeued(params.Name)
+			}
+		}
+		defer func() { <-h.toolSemaphore }()
+	}
+
+	if h.toolMetrics != nil {
+		h.toolMetrics.ToolCallStarted(params.Name)
+		defer h.toolMetrics.ToolCallFinished(params.Name)
+	}
+
+	// The request context is cancelled when the client disconnects, so
+	// long-running tools (notably SQL queries) abort instead of running on
+	result, err := h.dispatchTool(c.Request.Context(), params.Name, params.Arguments)
+	h.auditToolCall(sessionID, params, err)
+
+	var response MCPResponse
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to handle code assistance request")
-		c.JSON(http.StatusOK, MCPResponse{
+		// An unknown argument caught by strict decoding is the caller's
+		// mistake, not an internal failure
+		code, message := -32603, "Internal error"
+		if errors.Is(err, codeassist.ErrUnknownParam) {
+			code, message = -32602, "Invalid params"
+		}
+		response = MCPResponse{
 			JSONRPC: "2.0",
 			ID:      request.ID,
 			Error: &MCPError{
-				Code:    -32603,
-				Message: "Internal error",
+				Code:    code,
+				Message: message,
 				Data:    map[string]string{"detail": err.Error()},
 			},
-		})
+		}
+	} else {
+		response = MCPResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Result:  result,
+		}
+	}
+
+	echoMeta(request, &response)
+
+	// Cache only responses a retry could not improve on: successes and
+	// caller-fault errors. Replaying a transient internal failure (database
+	// down, context cancelled) for the full TTL would defeat the retry the
+	// idempotency key exists for.
+	if cacheKey != "" && (response.Error == nil || response.Error.Code == -32602) {
+		h.idempotency.store(cacheKey, response)
+	}
+
+	respond.JSON(c, http.StatusOK, response)
+}
+
+// dispatchTool routes a tool call to its implementation
+func (h *Handler) dispatchTool(ctx context.Context, name string, arguments json.RawMessage) (interface{}, error) {
+	switch name {
+	case "code_completion", "code_analysis", "code_documentation", "sql_format":
+		return h.codeAssistHandler.HandleMCPRequest(name, arguments)
+	case "query":
+		if h.safeMode.Load() {
+			return nil, errSafeMode
+		}
+		return h.executeQueryTool(ctx, arguments)
+	case "discover_data":
+		if h.safeMode.Load() {
+			return nil, errSafeMode
+		}
+		return h.executeDiscoverTool(ctx, arguments)
+	default:
+		return nil, fmt.Errorf("unknown tool: %s", name)
+	}
+}
+
+// executeDiscoverTool samples data from a table, applying the configured
+// discovery defaults when the request omits them
+func (h *Handler) executeDiscoverTool(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	if h.dbClient == nil {
+		return nil, fmt.Errorf("database is not configured")
+	}
+
+	var params struct {
+		Table      string `json:"table"`
+		SampleSize int    `json:"sampleSize,omitempty"`
+		Stats      *bool  `json:"stats,omitempty"`
+	}
+	if err := codeassist.UnmarshalParams(arguments, &params, h.strictParamsEnabled(ctx)); err != nil {
+		return nil, err
+	}
+	if params.Table == "" {
+		return nil, fmt.Errorf("table is required")
+	}
+
+	return h.dbClient.Discover(ctx, params.Table, params.SampleSize, params.Stats)
+}
+
+// executeQueryTool executes the query tool against the configured database
+func (h *Handler) executeQueryTool(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	if h.dbClient == nil {
+		return nil, fmt.Errorf("database is not configured")
+	}
+
+	var params struct {
+		Query    string          `json:"query"`
+		Params   json.RawMessage `json:"params,omitempty"`
+		Validate bool            `json:"validate"`
+		Format   string          `json:"format,omitempty"`
+	}
+	if err := codeassist.UnmarshalParams(arguments, &params, h.strictParamsEnabled(ctx)); err != nil {
+		return nil, err
+	}
+	if params.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	if params.Format != "" && params.Format != "json" && params.Format != "csv" {
+		return nil, fmt.Errorf("unsupported format: %s", params.Format)
+	}
+
+	// Enforce the allowed statement verbs for the query tool
+	if err := h.dbClient.CheckStatementAllowed("query", params.Query); err != nil {
+		return nil, err
+	}
+
+	// Bind positional or named params into the driver's placeholder style
+	query, args, err := h.dbClient.BindParams(params.Query, params.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	// Dry-run mode checks the statement via prepare without executing it
+	if params.Validate {
+		return h.dbClient.Validate(ctx, query)
+	}
+
+	result, err := h.dbClient.Query(ctx, query, args...)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			h.logger.Info("Query cancelled: client disconnected")
+		}
+		return nil, err
+	}
+
+	// Render the rows as CSV when requested, keeping JSON as the default
+	if params.Format == "csv" {
+		var buf bytes.Buffer
+		if err := result.WriteCSV(&buf); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"format":   "csv",
+			"content":  buf.String(),
+			"rowCount": result.RowCount,
+		}, nil
+	}
+
+	return result, nil
+}
+
+// HandleQueryExport streams the rows of a SELECT supplied via the query
+// parameter as a CSV download
+func (h *Handler) HandleQueryExport(c *gin.Context) {
+	if h.safeMode.Load() {
+		respond.JSON(c, http.StatusForbidden, gin.H{"error": errSafeMode.Error()})
+		return
+	}
+	if h.dbClient == nil {
+		respond.JSON(c, http.StatusServiceUnavailable, gin.H{"error": "database is not configured"})
 		return
 	}
 
-	c.JSON(http.StatusOK, MCPResponse{
-		JSONRPC: "2.0",
-		ID:      request.ID,
-		Result:  result,
-	})
+	query := c.Query("query")
+	if query == "" {
+		respond.JSON(c, http.StatusBadRequest, gin.H{"error": "query parameter is required"})
+		return
+	}
+
+	if err := h.dbClient.CheckStatementAllowed("query", query); err != nil {
+		respond.JSON(c, http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.dbClient.Query(c.Request.Context(), query)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to execute export query")
+		respond.JSON(c, http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="export.csv"`)
+	c.Status(http.StatusOK)
+	if err := result.WriteCSV(c.Writer); err != nil {
+		h.logger.WithError(err).Error("Failed to write CSV export")
+	}
+}
+
+// auditToolCall records a tool invocation in the audit log when enabled
+func (h *Handler) auditToolCall(client string, params ToolCallParams, callErr error) {
+	if h.auditWriter == nil {
+		return
+	}
+
+	outcome := "success"
+	if callErr != nil {
+		outcome = "error"
+	}
+
+	record := audit.Record{
+		Timestamp:  time.Now().UTC(),
+		Client:     client,
+		Tool:       params.Name,
+		ArgsDigest: audit.Digest(params.Arguments),
+		Outcome:    outcome,
+	}
+
+	if err := h.auditWriter.Write(record); err != nil {
+		h.logger.WithError(err).Error("Failed to write audit record")
+	}
+}
+
+// sendCapabilitiesEvent marshals the capabilities response and writes it as
+// an SSE event. A marshal failure must not push a garbled event at the
+// client, so it degrades to a structured error event instead.
+func (h *Handler) sendCapabilitiesEvent(c *gin.Context, clientID string, response MCPResponse) {
+	if responseJSON, err := json.Marshal(response); err != nil {
+		h.logger.WithError(err).Error("Failed to marshal SSE capabilities event")
+		c.SSEvent("", map[string]interface{}{
+			"type":     "error",
+			"clientId": clientID,
+			"error": map[string]interface{}{
+				"code":    -32603,
+				"message": "Internal error",
+				"data":    map[string]string{"detail": "failed to encode capabilities event"},
+			},
+		})
+	} else {
+		c.SSEvent("", string(responseJSON))
+	}
+	c.Writer.Flush()
 }
 
 // HandleSSE handles Server-Sent Events
 func (h *Handler) HandleSSE(c *gin.Context) {
+	// SSE can be switched off entirely for deployments that only use plain
+	// HTTP transports
+	if !h.cfg().SSEEnabled {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
 	h.logger.Info("Setting up SSE connection")
 
-	// Set headers for SSE
+	// Record the session duration on disconnect
+	sessionStart := time.Now()
+	defer func() {
+		if h.sseDurationObserver != nil {
+			h.sseDurationObserver(time.Since(sessionStart).Seconds())
+		}
+	}()
+
+	// Set headers for SSE. Transfer encoding is deliberately left to
+	// net/http, which negotiates chunking itself; setting the header manually
+	// can produce malformed responses.
 	c.Writer.Header().Set("Content-Type", "text/event-stream")
 	c.Writer.Header().Set("Cache-Control", "no-cache")
 	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("Transfer-Encoding", "chunked")
 
 	// Generate a client ID
 	clientID := fmt.Sprintf("%d", time.Now().UnixNano())
 
 	// Send ready event
 	c.SSEvent("", map[string]interface{}{
-		"type":     "ready",
-		"clientId": clientID,
+		"type":      "ready",
+		"clientId":  clientID,
+		"sessionId": h.sessionID(c),
 	})
 	c.Writer.Flush()
 
 	// Send capabilities
+	cfg := h.cfg()
 	response := MCPResponse{
 		JSONRPC: "2.0",
 		ID:      1,
 		Result: map[string]interface{}{
 			"capabilities": map[string]interface{}{
-				"serverName":    h.config.ServerName,
-				"serverVersion": h.config.ServerVersion,
-				"tools": []map[string]interface{}{
-					{
-						"name":        "list_tables",
-						"description": "List all available tables",
-						"parameters": map[string]interface{}{
-							"type":       "object",
-							"properties": map[string]interface{}{},
-						},
-					},
-					{
-						"name":        "discover_data",
-						"description": "Discover data in tables",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"table": map[string]interface{}{
-									"type":        "string",
-									"description": "Table name to discover",
-								},
-							},
-							"required": []string{"table"},
-						},
-					},
-					{
-						"name":        "prepare_query",
-						"description": "Prepare a SQL query",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"query": map[string]interface{}{
-									"type":        "string",
-									"description": "SQL query to prepare",
-								},
-							},
-							"required": []string{"query"},
-						},
-					},
-					{
-						"name":        "query",
-						"description": "Execute a SQL query",
-						"parameters": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"query": map[string]interface{}{
-									"type":        "string",
-									"description": "SQL query to execute",
-								},
-							},
-							"required": []string{"query"},
-						},
-					},
-				},
+				"serverName":    cfg.ServerName,
+				"serverVersion": cfg.ServerVersion,
+				"tools":         h.sqlTools(),
 				"capabilities": map[string]interface{}{
-					"supportedLanguages":       h.config.Capabilities.SupportedLanguages,
-					"supportsNotebooks":        h.config.Capabilities.SupportsNotebooks,
+					"supportedLanguages":        cfg.Capabilities.SupportedLanguages,
+					"supportsNotebooks":         cfg.Capabilities.SupportsNotebooks,
 					"supportsInlineCompletions": true,
 				},
 			},
 		},
 	}
 
-	responseJSON, _ := json.Marshal(response)
-	c.SSEvent("", string(responseJSON))
-	c.Writer.Flush()
+	h.sendCapabilitiesEvent(c, clientID, response)
 
-	// Keep the connection alive with heartbeats
-	ticker := time.NewTicker(10 * time.Second)
+	// Keep the connection alive with heartbeats; the ticker also paces the
+	// idle-reap check below
+	ticker := time.NewTicker(h.heartbeatInterval)
 	defer ticker.Stop()
 
 	// Use a channel to detect client disconnection
 	done := c.Request.Context().Done()
 
+	// Deliver server-to-client notifications queued for this session
+	sessionID := h.sessionID(c)
+	events := h.sessions.Events(sessionID)
+	h.sessions.Touch(sessionID)
+
+	// Writes go through a bounded buffer drained by a dedicated goroutine, so
+	// a slow consumer never blocks the delivery loop. send reports false when
+	// the buffer is full, at which point the connection is dropped. The
+	// goroutine is joined before the handler returns: net/http forbids using
+	// the ResponseWriter after ServeHTTP has returned. On a drop the remaining
+	// buffer is discarded rather than flushed, since further writes to a
+	// stalled connection can block indefinitely.
+	outbound := make(chan interface{}, sseOutboundBufferSize)
+	stopWriting := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for event := range outbound {
+			select {
+			case <-stopWriting:
+				continue
+			default:
+			}
+			c.SSEvent("", event)
+			c.Writer.Flush()
+		}
+	}()
+	defer func() {
+		close(outbound)
+		<-writerDone
+	}()
+
+	send := func(event interface{}) bool {
+		select {
+		case outbound <- event:
+			return true
+		default:
+			h.logger.WithFields(logrus.Fields{
+				"clientId": clientID,
+				"session":  sessionID,
+			}).Warn("Dropping SSE connection: client reading too slowly")
+			close(stopWriting)
+			if h.sseDropObserver != nil {
+				h.sseDropObserver()
+			}
+			return false
+		}
+	}
+
 	for {
 		select {
+		case event := <-events:
+			if !send(event) {
+				return
+			}
+			h.sessions.Touch(sessionID)
 		case <-ticker.C:
+			// Reap the connection when the session has been idle too long,
+			// so vanished clients don't pin goroutines until TCP notices
+			if h.sseIdleTimeout > 0 && time.Since(h.sessions.LastActivity(sessionID)) > h.sseIdleTimeout {
+				h.logger.WithFields(logrus.Fields{
+					"clientId": clientID,
+					"session":  sessionID,
+				}).Info("Closing idle SSE connection")
+				return
+			}
+
 			// Send heartbeat
 			timestamp := time.Now().UTC().Format(time.RFC3339)
-			c.SSEvent("", map[string]interface{}{
+			if !send(map[string]interface{}{
 				"type":      "heartbeat",
 				"timestamp": timestamp,
 				"clientId":  clientID,
-			})
-			c.Writer.Flush()
+			}) {
+				return
+			}
 		case <-done:
 			// Client disconnected
 			h.logger.WithField("clientId", clientID).Info("SSE client disconnected")
@@ -0,0 +1,302 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestHandler builds a handler with quiet logging for tests
+func newTestHandler(cfg *config.MCPConfig) *Handler {
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewHandler(cfg, logger)
+}
+
+// postMCP dispatches a raw JSON-RPC body through HandleMCPRequest for the
+// given session and returns the recorded response
+func postMCP(h *Handler, sessionID, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		c.Request.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	h.HandleMCPRequest(c)
+	return w
+}
+
+// decodeResponse unmarshals a recorded MCP response body
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) MCPResponse {
+	t.Helper()
+	var response MCPResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response body %q: %v", w.Body.String(), err)
+	}
+	return response
+}
+
+// sseContext builds a test context for HandleSSE writing to w, with the
+// session id carried as a query parameter the way EventSource reconnects do
+func sseContext(w http.ResponseWriter, sessionID string) *gin.Context {
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/sse?sessionId="+sessionID, nil)
+	return c
+}
+
+const toolCallBody = `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"sql_format","arguments":{"query":"select 1"}}}`
+
+func TestToolCallBeforeInitializedRejected(t *testing.T) {
+	h := newTestHandler(&config.MCPConfig{ProtocolVersion: "2.0"})
+
+	response := decodeResponse(t, postMCP(h, "session-1", toolCallBody))
+	if response.Error == nil || response.Error.Code != -32002 {
+		t.Fatalf("expected -32002 before initialization, got %+v", response)
+	}
+}
+
+func TestInitializedNotificationEnablesToolCalls(t *testing.T) {
+	h := newTestHandler(&config.MCPConfig{ProtocolVersion: "2.0"})
+
+	// initialize alone is not enough; normal operation starts only after the
+	// initialized notification
+	postMCP(h, "session-1", `{"jsonrpc":"2.0","id":1,"method":"initialize"}`)
+	response := decodeResponse(t, postMCP(h, "session-1", toolCallBody))
+	if response.Error == nil || response.Error.Code != -32002 {
+		t.Fatalf("expected -32002 after initialize only, got %+v", response)
+	}
+
+	postMCP(h, "session-1", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	response = decodeResponse(t, postMCP(h, "session-1", toolCallBody))
+	if response.Error != nil {
+		t.Fatalf("tool call after initialized notification failed: %+v", response.Error)
+	}
+	if response.Result == nil {
+		t.Fatal("tool call after initialized notification returned no result")
+	}
+}
+
+func TestDuplicateBatchIDsComparesRawTokens(t *testing.T) {
+	// The string "1" and the number 1 are distinct ids, not duplicates
+	if duplicates := duplicateBatchIDs([]byte(`[{"id":1},{"id":"1"}]`)); len(duplicates) != 0 {
+		t.Errorf("mixed-type ids flagged as duplicates: %v", duplicates)
+	}
+
+	if duplicates := duplicateBatchIDs([]byte(`[{"id":1},{"id":1}]`)); len(duplicates) != 1 || duplicates[0] != "1" {
+		t.Errorf("repeated numeric id not flagged: %v", duplicates)
+	}
+
+	// Notifications and null ids are exempt
+	if duplicates := duplicateBatchIDs([]byte(`[{"id":"a"},{"id":"a"},{},{"id":null},{"id":null}]`)); len(duplicates) != 1 || duplicates[0] != `"a"` {
+		t.Errorf("expected only the repeated string id: %v", duplicates)
+	}
+}
+
+func TestHandleSSEReapsStalledConnection(t *testing.T) {
+	h := newTestHandler(&config.MCPConfig{ProtocolVersion: "2.0", SSEEnabled: true})
+	h.sseIdleTimeout = 30 * time.Millisecond
+	h.heartbeatInterval = 10 * time.Millisecond
+
+	// A connection whose session sees no further activity must be reaped by
+	// the heartbeat loop instead of living until TCP notices
+	done := make(chan struct{})
+	go func() {
+		h.HandleSSE(sseContext(httptest.NewRecorder(), "stalled"))
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stalled SSE connection was not reaped")
+	}
+}
+
+// blockingWriter is a ResponseWriter whose Flush blocks after a set number of
+// flushes, simulating a client that has stopped reading
+type blockingWriter struct {
+	*httptest.ResponseRecorder
+	mu      sync.Mutex
+	flushes int
+	allowed int
+	gate    chan struct{}
+}
+
+func (w *blockingWriter) Flush() {
+	w.mu.Lock()
+	w.flushes++
+	blocked := w.flushes > w.allowed
+	w.mu.Unlock()
+	if blocked {
+		<-w.gate
+	}
+}
+
+func (w *blockingWriter) flushCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.flushes
+}
+
+func TestHandleSSEDropsSlowConsumerWithoutFlushingBuffer(t *testing.T) {
+	h := newTestHandler(&config.MCPConfig{ProtocolVersion: "2.0", SSEEnabled: true})
+	h.heartbeatInterval = time.Hour
+
+	drops := make(chan struct{}, 1)
+	h.SetSSEDropObserver(func() { drops <- struct{}{} })
+
+	// Allow the ready and capabilities flushes, then stall like a client that
+	// has stopped reading
+	w := &blockingWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		allowed:          2,
+		gate:             make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		h.HandleSSE(sseContext(w, "slow"))
+		close(done)
+	}()
+
+	// Flood the session until the outbound buffer overflows and the
+	// connection is dropped
+	deadline := time.After(5 * time.Second)
+	for dropped := false; !dropped; {
+		select {
+		case <-drops:
+			dropped = true
+		case <-deadline:
+			t.Fatal("slow consumer was not dropped")
+		default:
+			h.sessions.Push("slow", map[string]string{"type": "test"})
+		}
+	}
+
+	// The handler must not return while the writer goroutine is still blocked
+	// on the stalled connection
+	select {
+	case <-done:
+		t.Fatal("handler returned before the writer goroutine finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Unblock the stalled write; the writer must discard the remaining buffer
+	// instead of flushing it
+	close(w.gate)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler did not return after the writer goroutine finished")
+	}
+	if n := w.flushCount(); n != 3 {
+		t.Errorf("expected 3 flushes (ready, capabilities, one stalled write), got %d", n)
+	}
+}
+
+func TestSendCapabilitiesEventMarshalFailure(t *testing.T) {
+	h := newTestHandler(&config.MCPConfig{ProtocolVersion: "2.0"})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/sse", nil)
+
+	// A channel cannot be marshaled, forcing the error path
+	h.sendCapabilitiesEvent(c, "client-1", MCPResponse{
+		JSONRPC: "2.0",
+		ID:      1,
+		Result:  map[string]interface{}{"bad": make(chan int)},
+	})
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"type":"error"`) {
+		t.Errorf("expected a structured error event, got %q", body)
+	}
+	if !strings.Contains(body, "-32603") {
+		t.Errorf("expected error code -32603 in event, got %q", body)
+	}
+}
+
+func TestApplyConfigNotifiesOnCapabilityChange(t *testing.T) {
+	cfg := &config.MCPConfig{ProtocolVersion: "2.0"}
+	h := newTestHandler(cfg)
+
+	// Attach a session for the broadcast to reach
+	events := h.sessions.Events("session-1")
+
+	// Toggling safe mode withdraws the SQL tools, changing the advertised set
+	h.ApplyConfig(cfg, true)
+
+	select {
+	case event := <-events:
+		notification, ok := event.(MCPNotification)
+		if !ok || notification.Method != "notifications/tools/list_changed" {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	default:
+		t.Fatal("no notification broadcast after capabilities changed")
+	}
+
+	// An unchanged reload must stay quiet
+	h.ApplyConfig(cfg, true)
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected notification for unchanged reload: %+v", event)
+	default:
+	}
+}
+
+func TestApplyConfigConcurrentWithRequests(t *testing.T) {
+	base := &config.MCPConfig{
+		ProtocolVersion: "2.0",
+		Capabilities:    config.CapabilitiesConfig{SupportedLanguages: []string{"go"}},
+	}
+	h := newTestHandler(base)
+
+	// Hammer config readers while reloads land; under -race this catches
+	// unsynchronized access to the swapped configuration
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				_ = h.AdvertisedToolNames()
+				_ = h.strictParamsEnabled(context.Background())
+			}
+		}()
+	}
+
+	reloaded := &config.MCPConfig{
+		ProtocolVersion: "2.0",
+		StrictParams:    true,
+		Capabilities:    config.CapabilitiesConfig{SupportedLanguages: []string{"go", "python"}},
+	}
+	for i := 0; i < 100; i++ {
+		cfg := base
+		if i%2 == 1 {
+			cfg = reloaded
+		}
+		h.ApplyConfig(cfg, i%2 == 1)
+	}
+
+	close(stop)
+	wg.Wait()
+}
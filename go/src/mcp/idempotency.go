@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// idempotencyEntry is a cached tool-call response with its expiry
+type idempotencyEntry struct {
+	response MCPResponse
+	expires  time.Time
+}
+
+// idempotencyCache remembers tool-call responses by idempotency key so client
+// retries replay the original response instead of re-executing the tool.
+// Entries expire after the TTL, and the oldest entries are evicted once the
+// size limit is reached.
+type idempotencyCache struct {
+	mu         sync.Mutex
+	entries    map[string]idempotencyEntry
+	order      []string
+	ttl        time.Duration
+	maxEntries int
+}
+
+// newIdempotencyCache creates a cache with the given TTL and size limit
+func newIdempotencyCache(ttl time.Duration, maxEntries int) *idempotencyCache {
+	return &idempotencyCache{
+		entries:    make(map[string]idempotencyEntry),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// get returns the cached response for a key, if still valid
+func (c *idempotencyCache) get(key string) (MCPResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return MCPResponse{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		c.removeFromOrder(key)
+		return MCPResponse{}, false
+	}
+
+	return entry.response, true
+}
+
+// removeFromOrder drops a key from the eviction order, keeping the slice in
+// step with the entries map. The caller must hold the lock.
+func (c *idempotencyCache) removeFromOrder(key string) {
+	for i, existing := range c.order {
+		if existing == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// store caches a response for the TTL, evicting the oldest entries when full
+func (c *idempotencyCache) store(key string, response MCPResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = idempotencyEntry{
+		response: response,
+		expires:  time.Now().Add(c.ttl),
+	}
+
+	for len(c.entries) > c.maxEntries && len(c.order) > 0 {
+		oldest := c.order[0]
+		c.order = c.order[1:]
+		delete(c.entries, oldest)
+	}
+}
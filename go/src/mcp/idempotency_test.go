@@ -0,0 +1,98 @@
+package mcp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentTestHandler builds a handler with the idempotency cache
+// enabled and the session already initialized
+func newIdempotentTestHandler(t *testing.T, sessionID string) *Handler {
+	t.Helper()
+	h := newTestHandler(&config.MCPConfig{
+		ProtocolVersion: "2.0",
+		Idempotency:     config.IdempotencyConfig{Enabled: true, TTL: "60s", MaxEntries: 16},
+	})
+	h.sessions.MarkInitialized(sessionID)
+	return h
+}
+
+// postMCPWithKey dispatches a JSON-RPC body carrying an Idempotency-Key
+func postMCPWithKey(h *Handler, sessionID, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Mcp-Session-Id", sessionID)
+	c.Request.Header.Set("Idempotency-Key", key)
+	h.HandleMCPRequest(c)
+	return w
+}
+
+func TestIdempotentReplayReturnsCachedResult(t *testing.T) {
+	h := newIdempotentTestHandler(t, "session-1")
+
+	first := decodeResponse(t, postMCPWithKey(h, "session-1", "key-1", toolCallBody))
+	if first.Error != nil {
+		t.Fatalf("first tool call failed: %+v", first.Error)
+	}
+	if _, ok := h.idempotency.get("sql_format\x00key-1"); !ok {
+		t.Fatal("successful response was not cached")
+	}
+
+	retry := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"sql_format","arguments":{"query":"select 1"}}}`
+	second := decodeResponse(t, postMCPWithKey(h, "session-1", "key-1", retry))
+	if second.Error != nil {
+		t.Fatalf("replayed tool call failed: %+v", second.Error)
+	}
+	// The replay carries the retry's id but the original result
+	if id, ok := second.ID.(float64); !ok || id != 2 {
+		t.Errorf("replay did not echo the retry's id: %v", second.ID)
+	}
+}
+
+func TestExpiredEntriesLeaveNoOrderResidue(t *testing.T) {
+	cache := newIdempotencyCache(time.Millisecond, 4)
+
+	// Short-TTL keys must not accumulate in the eviction order after their
+	// entries expire, or the slice grows past the cap
+	for i := 0; i < 8; i++ {
+		key := fmt.Sprintf("key-%d", i%4)
+		cache.store(key, MCPResponse{})
+		time.Sleep(2 * time.Millisecond)
+		if _, ok := cache.get(key); ok {
+			t.Fatalf("entry %s did not expire", key)
+		}
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if len(cache.order) != 0 {
+		t.Errorf("expired keys left in eviction order: %v", cache.order)
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("expired entries left in map: %d", len(cache.entries))
+	}
+}
+
+func TestInternalErrorsAreNotCached(t *testing.T) {
+	h := newIdempotentTestHandler(t, "session-1")
+
+	// The query tool fails with an internal error when no database is
+	// configured; a retry with the same key must re-execute, not replay it
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"query","arguments":{"query":"select 1"}}}`
+	response := decodeResponse(t, postMCPWithKey(h, "session-1", "key-1", body))
+	if response.Error == nil || response.Error.Code != -32603 {
+		t.Fatalf("expected -32603 without a database, got %+v", response)
+	}
+	if _, ok := h.idempotency.get("query\x00key-1"); ok {
+		t.Fatal("internal error response was cached")
+	}
+}
@@ -0,0 +1,237 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// logLevelRank orders the MCP logging levels from most to least verbose
+var logLevelRank = map[string]int{
+	"debug":     0,
+	"info":      1,
+	"notice":    2,
+	"warning":   3,
+	"error":     4,
+	"critical":  5,
+	"alert":     6,
+	"emergency": 7,
+}
+
+// defaultSessionEventBuffer is the size of the per-session outbound event buffer
+const defaultSessionEventBuffer = 16
+
+// Session represents the state of a single MCP client session
+type Session struct {
+	ID            string
+	Initialized   bool
+	LogLevel      string
+	CreatedAt     time.Time
+	LastActivity  time.Time
+	CompletionSeq int64
+	events        chan interface{}
+}
+
+// SessionManager tracks per-session state for MCP clients
+type SessionManager struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewSessionManager creates a new session manager
+func NewSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[string]*Session),
+	}
+}
+
+// getOrCreateLocked returns the session for the given id, creating it if needed.
+// The caller must hold the write lock.
+func (m *SessionManager) getOrCreateLocked(id string) *Session {
+	session, ok := m.sessions[id]
+	if !ok {
+		now := time.Now().UTC()
+		session = &Session{
+			ID:           id,
+			LogLevel:     "info",
+			CreatedAt:    now,
+			LastActivity: now,
+			events:       make(chan interface{}, defaultSessionEventBuffer),
+		}
+		m.sessions[id] = session
+	}
+	return session
+}
+
+// GetOrCreate returns the session for the given id, creating it if needed
+func (m *SessionManager) GetOrCreate(id string) *Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getOrCreateLocked(id)
+}
+
+// AdvanceCompletionSeq records seq as the session's latest completion
+// sequence number and reports whether the request is current. A seq older
+// than the latest seen means the client has already typed past it, so the
+// caller can drop the request instead of doing the work. Unknown ids are
+// treated as current without creating a session.
+func (m *SessionManager) AdvanceCompletionSeq(id string, seq int64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok {
+		return true
+	}
+	if seq < session.CompletionSeq {
+		return false
+	}
+	session.CompletionSeq = seq
+	return true
+}
+
+// MarkInitialized marks the session as having completed the initialized handshake
+func (m *SessionManager) MarkInitialized(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.getOrCreateLocked(id).Initialized = true
+}
+
+// IsInitialized reports whether the session has completed the initialized handshake
+func (m *SessionManager) IsInitialized(id string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	session, ok := m.sessions[id]
+	return ok && session.Initialized
+}
+
+// SetLogLevel sets the minimum log level the session wants to receive.
+// Unknown ids are ignored, matching Touch.
+func (m *SessionManager) SetLogLevel(id, level string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if session, ok := m.sessions[id]; ok {
+		session.LogLevel = level
+	}
+}
+
+// ShouldLog reports whether a message at the given level passes the session's verbosity
+func (m *SessionManager) ShouldLog(id, level string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessionLevel := "info"
+	if session, ok := m.sessions[id]; ok {
+		sessionLevel = session.LogLevel
+	}
+
+	return logLevelRank[level] >= logLevelRank[sessionLevel]
+}
+
+// Touch records activity on the session, resetting its idle clock. Unknown
+// ids are ignored: sessions are created by the initialize handshake or an SSE
+// attach, not by arbitrary requests, so unsolicited traffic carrying random
+// session ids cannot inflate the map.
+func (m *SessionManager) Touch(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if session, ok := m.sessions[id]; ok {
+		session.LastActivity = time.Now().UTC()
+	}
+}
+
+// EvictIdle removes sessions whose last activity is older than idleTimeout
+// and returns the number evicted. Without eviction the map grows without
+// bound under ordinary session churn.
+func (m *SessionManager) EvictIdle(idleTimeout time.Duration) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-idleTimeout)
+	evicted := 0
+	for id, session := range m.sessions {
+		if session.LastActivity.Before(cutoff) {
+			delete(m.sessions, id)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// Len returns the number of tracked sessions
+func (m *SessionManager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.sessions)
+}
+
+// LastActivity returns the time of the session's most recent activity
+func (m *SessionManager) LastActivity(id string) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if session, ok := m.sessions[id]; ok {
+		return session.LastActivity
+	}
+	return time.Time{}
+}
+
+// Push queues an event for delivery to the session's streaming connection.
+// It never blocks; the event is dropped when the buffer is full or the
+// session is unknown.
+func (m *SessionManager) Push(id string, event interface{}) bool {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	select {
+	case session.events <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// Broadcast queues an event for every known session, dropping it for
+// sessions whose buffers are full. It returns the number of sessions the
+// event was delivered to.
+func (m *SessionManager) Broadcast(event interface{}) int {
+	m.mu.RLock()
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.mu.RUnlock()
+
+	delivered := 0
+	for _, session := range sessions {
+		select {
+		case session.events <- event:
+			delivered++
+		default:
+		}
+	}
+	return delivered
+}
+
+// Events returns the outbound event channel for the session
+func (m *SessionManager) Events(id string) <-chan interface{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.getOrCreateLocked(id).events
+}
+
+// IsValidLogLevel reports whether the given level is a known MCP logging level
+func IsValidLogLevel(level string) bool {
+	_, ok := logLevelRank[level]
+	return ok
+}
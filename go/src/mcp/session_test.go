@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTouchDoesNotCreateSession(t *testing.T) {
+	manager := NewSessionManager()
+
+	manager.Touch("unknown")
+
+	if n := manager.Len(); n != 0 {
+		t.Fatalf("Touch created a session: %d tracked", n)
+	}
+}
+
+func TestEvictIdleRemovesStaleSessions(t *testing.T) {
+	manager := NewSessionManager()
+	manager.GetOrCreate("stale")
+	manager.GetOrCreate("fresh")
+
+	// Backdate the stale session past the idle cutoff
+	manager.mu.Lock()
+	manager.sessions["stale"].LastActivity = time.Now().UTC().Add(-time.Hour)
+	manager.mu.Unlock()
+
+	if evicted := manager.EvictIdle(30 * time.Minute); evicted != 1 {
+		t.Fatalf("expected 1 eviction, got %d", evicted)
+	}
+	if n := manager.Len(); n != 1 {
+		t.Fatalf("expected 1 remaining session, got %d", n)
+	}
+	if manager.LastActivity("fresh").IsZero() {
+		t.Error("fresh session was evicted")
+	}
+	if !manager.LastActivity("stale").IsZero() {
+		t.Error("stale session was not evicted")
+	}
+}
+
+func TestUnknownSessionPathsDoNotCreate(t *testing.T) {
+	manager := NewSessionManager()
+
+	// Every path reachable with an arbitrary session id must leave the map
+	// alone; only the initialize handshake and an SSE attach create sessions
+	manager.Touch("unknown")
+	manager.SetLogLevel("unknown", "debug")
+	if !manager.AdvanceCompletionSeq("unknown", 1) {
+		t.Error("AdvanceCompletionSeq should treat unknown sessions as current")
+	}
+	if manager.Push("unknown", "event") {
+		t.Error("Push should drop events for unknown sessions")
+	}
+
+	if n := manager.Len(); n != 0 {
+		t.Fatalf("unknown-id paths created sessions: %d tracked", n)
+	}
+}
+
+func TestAdvanceCompletionSeqDropsOlderSeq(t *testing.T) {
+	manager := NewSessionManager()
+	manager.GetOrCreate("session")
+
+	if !manager.AdvanceCompletionSeq("session", 2) {
+		t.Error("first seq should be current")
+	}
+	if !manager.AdvanceCompletionSeq("session", 2) {
+		t.Error("equal seq should be current")
+	}
+	if manager.AdvanceCompletionSeq("session", 1) {
+		t.Error("older seq should be stale")
+	}
+	if !manager.AdvanceCompletionSeq("session", 3) {
+		t.Error("newer seq should be current")
+	}
+}
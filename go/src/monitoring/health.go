@@ -1,26 +1,84 @@
 package monitoring
 
 import (
+	"context"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/cploutarchou/mcp-servers/go/respond"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
+// defaultCheckTimeout bounds dependency health checks when no timeout is configured
+const defaultCheckTimeout = 10 * time.Second
+
+// DependencyCheck pings a dependency (e.g. the database) and returns an error
+// when it is unhealthy
+type DependencyCheck func(ctx context.Context) error
+
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	config *config.MCPConfig
-	logger *logrus.Logger
+	config          *config.MCPConfig
+	logger          *logrus.Logger
+	checkTimeout    time.Duration
+	dependencyCheck DependencyCheck
+	cacheTTL        time.Duration
+	cacheMu         sync.Mutex
+	lastSuccess     time.Time
 }
 
 // NewHealthHandler creates a new health check handler
 func NewHealthHandler(config *config.MCPConfig, logger *logrus.Logger) *HealthHandler {
 	return &HealthHandler{
-		config: config,
-		logger: logger,
+		config:       config,
+		logger:       logger,
+		checkTimeout: defaultCheckTimeout,
+	}
+}
+
+// SetCheckTimeout sets the timeout applied to dependency health checks
+func (h *HealthHandler) SetCheckTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.checkTimeout = timeout
+	}
+}
+
+// SetDependencyCheck registers a dependency check run on each health request
+func (h *HealthHandler) SetDependencyCheck(check DependencyCheck) {
+	h.dependencyCheck = check
+}
+
+// SetCacheTTL sets how long a successful dependency check is reused before
+// the dependency is pinged again. Zero disables caching.
+func (h *HealthHandler) SetCacheTTL(ttl time.Duration) {
+	h.cacheTTL = ttl
+}
+
+// checkDependency runs the dependency check, reusing a recent successful
+// result so rapid health polls don't each ping the database. Failures are
+// never cached: an unhealthy dependency is re-checked on every poll, so the
+// status flips quickly both on failure and on recovery.
+func (h *HealthHandler) checkDependency(ctx context.Context) error {
+	if h.cacheTTL > 0 {
+		h.cacheMu.Lock()
+		fresh := time.Since(h.lastSuccess) < h.cacheTTL
+		h.cacheMu.Unlock()
+		if fresh {
+			return nil
+		}
 	}
+
+	err := h.dependencyCheck(ctx)
+	if err == nil && h.cacheTTL > 0 {
+		h.cacheMu.Lock()
+		h.lastSuccess = time.Now()
+		h.cacheMu.Unlock()
+	}
+	return err
 }
 
 // HealthResponse represents a health check response
@@ -35,12 +93,35 @@ type HealthResponse struct {
 func (h *HealthHandler) HandleHealthCheck(c *gin.Context) {
 	h.logger.Info("Handling health check request")
 
+	status := "ok"
+	httpStatus := http.StatusOK
+
+	// Run the dependency check under the configured timeout so a slow
+	// dependency degrades the status instead of hanging the endpoint
+	if h.dependencyCheck != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), h.checkTimeout)
+		defer cancel()
+
+		if err := h.checkDependency(ctx); err != nil {
+			h.logger.WithError(err).Warn("Dependency health check failed")
+			status = "degraded"
+			httpStatus = http.StatusServiceUnavailable
+		}
+	}
+
+	// Plaintext probes just want the bare status; keep the rich JSON for
+	// everything else
+	if strings.Contains(c.GetHeader("Accept"), "text/plain") {
+		c.String(httpStatus, status)
+		return
+	}
+
 	response := HealthResponse{
-		Status:    "ok",
+		Status:    status,
 		Timestamp: time.Now().UTC(),
 		Version:   h.config.ServerVersion,
 		Service:   h.config.ServerName,
 	}
 
-	c.JSON(http.StatusOK, response)
+	respond.JSON(c, httpStatus, response)
 }
@@ -1,8 +1,11 @@
 package monitoring
 
 import (
+	"crypto/subtle"
 	"fmt"
-	
+	"net/http"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -11,52 +14,249 @@ import (
 
 // MetricsHandler handles metrics requests
 type MetricsHandler struct {
-	logger      *logrus.Logger
-	registry    *prometheus.Registry
-	requestsTotal *prometheus.CounterVec
-	requestDuration *prometheus.HistogramVec
-	activeConnections *prometheus.GaugeVec
+	logger               *logrus.Logger
+	auth                 *config.MetricsAuthConfig
+	registry             *prometheus.Registry
+	requestsTotal        *prometheus.CounterVec
+	requestDuration      *prometheus.HistogramVec
+	activeConnections    *prometheus.GaugeVec
+	sseSessionDuration   prometheus.Histogram
+	toolInflight         *prometheus.GaugeVec
+	toolQueueDepth       *prometheus.GaugeVec
+	sseSlowConsumerDrops prometheus.Counter
+	cacheHits            *prometheus.CounterVec
+	analysisDiagnostics  *prometheus.CounterVec
+	cacheMisses          *prometheus.CounterVec
 }
 
 // NewMetricsHandler creates a new metrics handler
-func NewMetricsHandler(logger *logrus.Logger) *MetricsHandler {
+func NewMetricsHandler(cfg *config.MetricsConfig, logger *logrus.Logger) *MetricsHandler {
 	registry := prometheus.NewRegistry()
-	
+
+	namespace := cfg.Namespace
+	if namespace == "" {
+		namespace = "mcp"
+	}
+	constLabels := prometheus.Labels(cfg.ConstLabels)
+
 	requestsTotal := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
-			Name: "mcp_requests_total",
-			Help: "Total number of MCP requests",
+			Namespace:   namespace,
+			Name:        "requests_total",
+			Help:        "Total number of MCP requests",
+			ConstLabels: constLabels,
 		},
 		[]string{"method", "status"},
 	)
-	
+
 	requestDuration := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name: "mcp_request_duration_seconds",
-			Help: "Duration of MCP requests in seconds",
-			Buckets: prometheus.DefBuckets,
+			Namespace:   namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Duration of MCP requests in seconds",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
 		},
 		[]string{"method"},
 	)
-	
+
 	activeConnections := prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "mcp_active_connections",
-			Help: "Number of active MCP connections",
+			Namespace:   namespace,
+			Name:        "active_connections",
+			Help:        "Number of active MCP connections",
+			ConstLabels: constLabels,
 		},
 		[]string{"type"},
 	)
-	
+
+	// Buckets span one second to a few hours, matching observed SSE session lifetimes
+	sseSessionDuration := prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "sse_session_duration_seconds",
+			Help:        "Duration of SSE sessions in seconds",
+			Buckets:     prometheus.ExponentialBuckets(1, 4, 8),
+			ConstLabels: constLabels,
+		},
+	)
+
+	toolInflight := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "tool_inflight",
+			Help:        "Number of tool calls currently executing",
+			ConstLabels: constLabels,
+		},
+		[]string{"tool"},
+	)
+
+	toolQueueDepth := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "tool_queue_depth",
+			Help:        "Number of tool calls waiting for a concurrency slot",
+			ConstLabels: constLabels,
+		},
+		[]string{"tool"},
+	)
+
+	sseSlowConsumerDrops := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "sse_slow_consumer_drops_total",
+			Help:        "Total number of SSE connections dropped because the client read too slowly",
+			ConstLabels: constLabels,
+		},
+	)
+
+	cacheHits := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_hits_total",
+			Help:        "Total number of cache hits, by cache",
+			ConstLabels: constLabels,
+		},
+		[]string{"cache"},
+	)
+
+	cacheMisses := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "cache_misses_total",
+			Help:        "Total number of cache misses, by cache",
+			ConstLabels: constLabels,
+		},
+		[]string{"cache"},
+	)
+
+	analysisDiagnostics := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "analysis_diagnostics_total",
+			Help:        "Total number of analysis diagnostics emitted, by language and severity",
+			ConstLabels: constLabels,
+		},
+		[]string{"language", "severity"},
+	)
+
 	registry.MustRegister(requestsTotal)
 	registry.MustRegister(requestDuration)
 	registry.MustRegister(activeConnections)
-	
+	registry.MustRegister(sseSessionDuration)
+	registry.MustRegister(toolInflight)
+	registry.MustRegister(toolQueueDepth)
+	registry.MustRegister(sseSlowConsumerDrops)
+	registry.MustRegister(cacheHits)
+	registry.MustRegister(cacheMisses)
+	registry.MustRegister(analysisDiagnostics)
+
 	return &MetricsHandler{
-		logger:      logger,
-		registry:    registry,
-		requestsTotal: requestsTotal,
-		requestDuration: requestDuration,
-		activeConnections: activeConnections,
+		logger:               logger,
+		auth:                 &cfg.Auth,
+		registry:             registry,
+		requestsTotal:        requestsTotal,
+		requestDuration:      requestDuration,
+		activeConnections:    activeConnections,
+		sseSessionDuration:   sseSessionDuration,
+		toolInflight:         toolInflight,
+		toolQueueDepth:       toolQueueDepth,
+		sseSlowConsumerDrops: sseSlowConsumerDrops,
+		cacheHits:            cacheHits,
+		cacheMisses:          cacheMisses,
+		analysisDiagnostics:  analysisDiagnostics,
+	}
+}
+
+// CollectorCount returns the number of metric families the registry serves
+func (h *MetricsHandler) CollectorCount() int {
+	families, err := h.registry.Gather()
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to gather metric families")
+	}
+	return len(families)
+}
+
+// CacheHit counts a hit on the named cache
+func (h *MetricsHandler) CacheHit(cache string) {
+	h.cacheHits.WithLabelValues(cache).Inc()
+}
+
+// CacheMiss counts a miss on the named cache
+func (h *MetricsHandler) CacheMiss(cache string) {
+	h.cacheMisses.WithLabelValues(cache).Inc()
+}
+
+// AnalysisDiagnostics counts diagnostics emitted by an analysis run, broken
+// down by language and severity
+func (h *MetricsHandler) AnalysisDiagnostics(language, severity string, count int) {
+	h.analysisDiagnostics.WithLabelValues(language, severity).Add(float64(count))
+}
+
+// IncrementSSESlowConsumerDrops counts an SSE connection dropped for reading
+// too slowly
+func (h *MetricsHandler) IncrementSSESlowConsumerDrops() {
+	h.sseSlowConsumerDrops.Inc()
+}
+
+// ToolCallStarted marks a tool call as executing
+func (h *MetricsHandler) ToolCallStarted(tool string) {
+	h.toolInflight.WithLabelValues(tool).Inc()
+}
+
+// ToolCallFinished marks a tool call as no longer executing
+func (h *MetricsHandler) ToolCallFinished(tool string) {
+	h.toolInflight.WithLabelValues(tool).Dec()
+}
+
+// ToolCallQueued marks a tool call as waiting for a concurrency slot
+func (h *MetricsHandler) ToolCallQueued(tool string) {
+	h.toolQueueDepth.WithLabelValues(tool).Inc()
+}
+
+// ToolCallDequeued marks a queued tool call as having obtained a slot
+func (h *MetricsHandler) ToolCallDequeued(tool string) {
+	h.toolQueueDepth.WithLabelValues(tool).Dec()
+}
+
+// ObserveSSESessionDuration records how long an SSE connection lasted
+func (h *MetricsHandler) ObserveSSESessionDuration(seconds float64) {
+	h.sseSessionDuration.Observe(seconds)
+}
+
+// AuthMiddleware guards the metrics endpoint with the configured bearer token
+// or IP allowlist. With no auth configured, every request passes.
+func (h *MetricsHandler) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.auth.BearerToken == "" && len(h.auth.AllowedIPs) == 0 {
+			c.Next()
+			return
+		}
+
+		if h.auth.BearerToken != "" {
+			// Constant-time compare: a short-circuiting == would leak how much
+			// of the token matched through response timing
+			expected := []byte("Bearer " + h.auth.BearerToken)
+			if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		clientIP := c.ClientIP()
+		for _, ip := range h.auth.AllowedIPs {
+			if ip == clientIP {
+				c.Next()
+				return
+			}
+		}
+
+		h.logger.WithField("client", clientIP).Warn("Unauthorized metrics request")
+		if h.auth.BearerToken != "" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.AbortWithStatus(http.StatusForbidden)
 	}
 }
 
@@ -96,9 +296,9 @@ func (h *MetricsHandler) DecrementActiveConnections(connectionType string) {
 func (h *MetricsHandler) MetricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := prometheus.NewTimer(h.requestDuration.WithLabelValues(c.Request.Method))
-		
+
 		c.Next()
-		
+
 		start.ObserveDuration()
 		h.IncrementRequestsTotal(c.Request.Method, fmt.Sprintf("%d", c.Writer.Status()))
 	}
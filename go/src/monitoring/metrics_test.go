@@ -0,0 +1,77 @@
+package monitoring
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cploutarchou/mcp-servers/go/config"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// authStatus runs a request through AuthMiddleware with the given auth
+// configuration and returns the resulting status code
+func authStatus(t *testing.T, auth config.MetricsAuthConfig, mutate func(*http.Request)) int {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	h := NewMetricsHandler(&config.MetricsConfig{Auth: auth}, logger)
+	router := gin.New()
+	router.GET("/metrics", h.AuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if mutate != nil {
+		mutate(request)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	return w.Code
+}
+
+func TestAuthMiddlewareBearerToken(t *testing.T) {
+	auth := config.MetricsAuthConfig{BearerToken: "secret-token"}
+
+	if code := authStatus(t, auth, func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer secret-token")
+	}); code != http.StatusOK {
+		t.Errorf("valid token rejected: %d", code)
+	}
+
+	if code := authStatus(t, auth, nil); code != http.StatusUnauthorized {
+		t.Errorf("missing token passed: %d", code)
+	}
+
+	if code := authStatus(t, auth, func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer wrong-token")
+	}); code != http.StatusUnauthorized {
+		t.Errorf("wrong token passed: %d", code)
+	}
+}
+
+func TestAuthMiddlewareIPAllowlist(t *testing.T) {
+	auth := config.MetricsAuthConfig{AllowedIPs: []string{"10.0.0.5"}}
+
+	if code := authStatus(t, auth, func(r *http.Request) {
+		r.RemoteAddr = "10.0.0.5:42731"
+	}); code != http.StatusOK {
+		t.Errorf("allowed IP rejected: %d", code)
+	}
+
+	if code := authStatus(t, auth, func(r *http.Request) {
+		r.RemoteAddr = "10.0.0.6:42731"
+	}); code != http.StatusForbidden {
+		t.Errorf("disallowed IP passed: %d", code)
+	}
+}
+
+func TestAuthMiddlewareOpenWithoutConfig(t *testing.T) {
+	if code := authStatus(t, config.MetricsAuthConfig{}, nil); code != http.StatusOK {
+		t.Errorf("unconfigured auth blocked the endpoint: %d", code)
+	}
+}
@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/cploutarchou/mcp-servers/go/respond"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SelfCheckSection produces one named section of the self-check report
+type SelfCheckSection func() interface{}
+
+// selfCheckEntry pairs a section with its name, keeping report order stable
+type selfCheckEntry struct {
+	name    string
+	section SelfCheckSection
+}
+
+// SelfCheckHandler reports how the process is wired at runtime, for ops
+// troubleshooting. Sections are contributed by the components assembled in
+// main, so the report reflects the actual process rather than the expected
+// configuration.
+type SelfCheckHandler struct {
+	logger   *logrus.Logger
+	sections []selfCheckEntry
+}
+
+// NewSelfCheckHandler creates a new self-check handler
+func NewSelfCheckHandler(logger *logrus.Logger) *SelfCheckHandler {
+	return &SelfCheckHandler{
+		logger: logger,
+	}
+}
+
+// AddSection registers a named report section. Call before serving.
+func (h *SelfCheckHandler) AddSection(name string, section SelfCheckSection) {
+	h.sections = append(h.sections, selfCheckEntry{name: name, section: section})
+}
+
+// HandleSelfCheck renders every registered section plus the runtime basics
+func (h *SelfCheckHandler) HandleSelfCheck(c *gin.Context) {
+	h.logger.Info("Handling self-check request")
+
+	report := gin.H{
+		"goroutines": runtime.NumGoroutine(),
+	}
+	for _, entry := range h.sections {
+		report[entry.name] = entry.section()
+	}
+
+	respond.JSON(c, http.StatusOK, report)
+}
@@ -0,0 +1,55 @@
+package respond
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Drainer gates requests during graceful shutdown. Once Drain is called, new
+// requests receive a clean 503 with a Retry-After header instead of a
+// connection error from a closed listener, while in-flight requests finish
+// undisturbed.
+type Drainer struct {
+	draining   atomic.Bool
+	retryAfter string
+}
+
+// NewDrainer creates a drainer advertising retryAfter as the time clients
+// should wait before retrying — typically the shutdown timeout, after which
+// a replacement instance is serving
+func NewDrainer(retryAfter time.Duration) *Drainer {
+	seconds := int(retryAfter.Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &Drainer{
+		retryAfter: strconv.Itoa(seconds),
+	}
+}
+
+// Drain switches the drainer into draining mode; there is no way back, as a
+// draining process is on its way out
+func (d *Drainer) Drain() {
+	d.draining.Store(true)
+}
+
+// Middleware rejects new requests with 503 Service Unavailable once draining
+// has begun
+func (d *Drainer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !d.draining.Load() {
+			c.Next()
+			return
+		}
+
+		c.Header("Retry-After", d.retryAfter)
+		JSON(c, http.StatusServiceUnavailable, gin.H{
+			"error": "server is shutting down",
+		})
+		c.Abort()
+	}
+}
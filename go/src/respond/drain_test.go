@@ -0,0 +1,63 @@
+package respond
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// drainTestRouter wires the drainer's middleware in front of a trivial handler
+func drainTestRouter(d *Drainer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(d.Middleware())
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestDrainerPassesRequestsBeforeDrain(t *testing.T) {
+	router := drainTestRouter(NewDrainer(30 * time.Second))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("request blocked before draining: %d", w.Code)
+	}
+}
+
+func TestDrainerRejectsRequestsWhileDraining(t *testing.T) {
+	d := NewDrainer(30 * time.Second)
+	router := drainTestRouter(d)
+	d.Drain()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 while draining, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("expected Retry-After 30, got %q", got)
+	}
+	if !strings.Contains(w.Body.String(), "shutting down") {
+		t.Errorf("expected a structured shutdown error, got %q", w.Body.String())
+	}
+}
+
+func TestDrainerRetryAfterFloorsAtOneSecond(t *testing.T) {
+	d := NewDrainer(0)
+	router := drainTestRouter(d)
+	d.Drain()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package respond
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JSON writes the object as JSON, indented when the request carries
+// ?pretty=true. The compact default keeps responses efficient; the pretty
+// form exists for manual curl debugging.
+func JSON(c *gin.Context, status int, obj interface{}) {
+	if c.Query("pretty") == "true" {
+		c.IndentedJSON(status, obj)
+		return
+	}
+	c.JSON(status, obj)
+}
+
+// RequireJSON is a middleware rejecting POST bodies whose Content-Type is
+// not JSON with a 415 and a JSON-RPC error, so Gin's binding never sees a
+// mis-typed body. application/json-rpc is accepted alongside
+// application/json; GET and other bodyless methods pass through untouched.
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		contentType := c.ContentType()
+		if contentType == "application/json" || contentType == "application/json-rpc" ||
+			strings.HasSuffix(contentType, "+json") {
+			c.Next()
+			return
+		}
+
+		JSON(c, http.StatusUnsupportedMediaType, gin.H{
+			"jsonrpc": "2.0",
+			"id":      nil,
+			"error": gin.H{
+				"code":    -32600,
+				"message": "Invalid Request",
+				"data": gin.H{
+					"detail": "Content-Type must be application/json",
+				},
+			},
+		})
+		c.Abort()
+	}
+}